@@ -6,10 +6,14 @@ import (
 	"time"
 
 	"github.com/gloryhry/jimeng-api-go/internal/api/routes"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/audit"
 	"github.com/gloryhry/jimeng-api-go/internal/pkg/config"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/janitor"
 	"github.com/gloryhry/jimeng-api-go/internal/pkg/logger"
 	"github.com/gloryhry/jimeng-api-go/internal/pkg/proxy"
 	"github.com/gloryhry/jimeng-api-go/internal/pkg/server"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/storage"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/uploader"
 )
 
 const version = "1.6.3"
@@ -37,12 +41,90 @@ func main() {
 	// 初始化代理配置
 	proxy.Setup()
 
+	// 初始化审计日志
+	if config.System.Audit.Enabled {
+		auditConfig := config.System.Audit
+		audit.Init(audit.Options{
+			Dir:           config.System.AuditDirPath(),
+			Rotation:      auditConfig.Rotation,
+			MaxSize:       auditConfig.MaxSize * 1024 * 1024,
+			MaxAge:        time.Duration(auditConfig.MaxAge) * 24 * time.Hour,
+			MaxBackups:    auditConfig.MaxBackups,
+			WriteInterval: time.Duration(auditConfig.WriteInterval) * time.Millisecond,
+		})
+		logger.Info(fmt.Sprintf("审计日志已开启: %s", config.System.AuditDirPath()))
+	}
+
 	logger.Info("<<<< jimeng free server >>>>")
 	logger.Info(fmt.Sprintf("Version: %s", version))
 	logger.Info(fmt.Sprintf("Process id: %d", os.Getpid()))
 	logger.Info(fmt.Sprintf("Environment: %s", config.Environment))
 	logger.Info(fmt.Sprintf("Service name: %s", config.Service.Name))
 
+	// 初始化结果转存
+	if config.System.Storage.Enabled {
+		storageConfig := config.System.Storage
+		if err := storage.Init(storage.Options{
+			Driver:        storageConfig.Driver,
+			LocalDir:      config.System.StorageDirPath(),
+			PublicBaseURL: storageConfig.PublicBaseURL,
+			ResponseMode:  storageConfig.ResponseMode,
+			Prefix:        storageConfig.Prefix,
+			S3: storage.S3Options{
+				Endpoint:       storageConfig.S3.Endpoint,
+				Region:         storageConfig.S3.Region,
+				Bucket:         storageConfig.S3.Bucket,
+				AccessKey:      storageConfig.S3.AccessKey,
+				SecretKey:      storageConfig.S3.SecretKey,
+				PathStyle:      storageConfig.S3.PathStyle,
+				ACL:            storageConfig.S3.ACL,
+				PublicBaseURL:  storageConfig.S3.PublicBaseURL,
+				Presign:        storageConfig.S3.Presign,
+				PresignExpires: time.Duration(storageConfig.S3.PresignExpires) * time.Second,
+				TmpDir:         config.System.TmpDirPath(),
+			},
+			Signing: storage.SigningOptions{
+				Secret:  storageConfig.Signing.Secret,
+				TTL:     time.Duration(storageConfig.Signing.TTL) * time.Second,
+				MaxTTL:  time.Duration(storageConfig.Signing.MaxTTL) * time.Second,
+				Require: storageConfig.Signing.RequireSignature,
+			},
+		}); err != nil {
+			logger.Error(fmt.Sprintf("初始化结果转存失败: %v", err))
+			os.Exit(1)
+		}
+		logger.Info(fmt.Sprintf("结果转存已开启: %s", storageConfig.Driver))
+	}
+
+	// 上传分片与策略参数
+	uploader.SetChunkOptions(uploader.ChunkOptions{
+		PartSize:   config.System.UploadChunkSize * 1024 * 1024,
+		MaxRetries: config.System.UploadPartRetries,
+		TmpDir:     config.System.TmpDirPath(),
+	})
+	if err := uploader.SetRegionStrategies(config.System.UploadStrategies); err != nil {
+		logger.Error(fmt.Sprintf("上传策略配置无效: %v", err))
+		os.Exit(1)
+	}
+
+	// 初始化图片上传缓存
+	if config.System.UploadCache.Enabled {
+		uploadCacheConfig := config.System.UploadCache
+		if err := uploader.InitCache(uploader.CacheOptions{
+			Path:         config.System.UploadCachePath(),
+			TTL:          time.Duration(uploadCacheConfig.TTL) * time.Second,
+			MaxEntries:   uploadCacheConfig.MaxEntries,
+			SaveInterval: time.Duration(uploadCacheConfig.SaveInterval) * time.Second,
+		}); err != nil {
+			logger.Warn(fmt.Sprintf("加载上传缓存失败，将使用空缓存: %v", err))
+		}
+	}
+
+	// 启动过期文件清理
+	if config.System.Janitor.Enabled {
+		janitor.Init(janitorOptions())
+	}
+
 	// 创建服务器
 	srv := server.NewServer()
 
@@ -61,7 +143,50 @@ func main() {
 	// 等待中断信号
 	srv.Wait()
 
+	// 停止过期文件清理
+	janitor.Destroy()
+
+	// 写入剩余审计记录
+	audit.Destroy()
+
+	// 保存上传缓存索引
+	uploader.DestroyCache()
+
 	// 输出日志尾部
 	logger.Footer()
 	logger.Destroy()
 }
+
+// janitorOptions 根据系统配置生成清理规则，日志目录只清理顶层文件（审计日志有独立的保留策略）
+func janitorOptions() janitor.Options {
+	janitorConfig := config.System.Janitor
+	rules := []janitor.Rule{
+		{
+			Name:      "tmp",
+			Dir:       config.System.TmpDirPath(),
+			MaxAge:    time.Duration(config.System.TmpFileExpires) * time.Millisecond,
+			MaxSize:   janitorConfig.TmpMaxSize * 1024 * 1024,
+			Recursive: true,
+		},
+		{
+			Name:    "logs",
+			Dir:     config.System.LogDirPath(),
+			MaxAge:  time.Duration(config.System.LogFileExpires) * time.Millisecond,
+			MaxSize: janitorConfig.LogMaxSize * 1024 * 1024,
+		},
+	}
+	storageConfig := config.System.Storage
+	if storageConfig.Enabled && (storageConfig.Driver == "" || storageConfig.Driver == "local") {
+		rules = append(rules, janitor.Rule{
+			Name:      "storage",
+			Dir:       config.System.StorageDirPath(),
+			MaxAge:    time.Duration(janitorConfig.StorageExpires) * time.Millisecond,
+			MaxSize:   janitorConfig.StorageMaxSize * 1024 * 1024,
+			Recursive: true,
+		})
+	}
+	return janitor.Options{
+		Interval: time.Duration(janitorConfig.Interval) * time.Second,
+		Rules:    rules,
+	}
+}
@@ -0,0 +1,44 @@
+package builders
+
+import "fmt"
+
+// 图生图参考图角色
+const (
+	BlendRoleSubject    = "subject"    // 主体
+	BlendRoleStyle      = "style"      // 风格
+	BlendRoleBackground = "background" // 背景
+)
+
+// BlendReference 图生图中单张参考图的角色和强度，Strength 为 0 时使用全局强度
+type BlendReference struct {
+	Role     string  `json:"role,omitempty"`
+	Strength float64 `json:"strength,omitempty"`
+}
+
+// Validate 校验角色取值和强度范围 (0, 1]
+func (r BlendReference) Validate() error {
+	switch r.Role {
+	case "", BlendRoleSubject, BlendRoleStyle, BlendRoleBackground:
+	default:
+		return fmt.Errorf("role 必须是 %s、%s 或 %s", BlendRoleSubject, BlendRoleStyle, BlendRoleBackground)
+	}
+	if r.Strength < 0 || r.Strength > 1 {
+		return fmt.Errorf("strength 必须在 0 到 1 之间")
+	}
+	return nil
+}
+
+// BlendRoles 返回各参考图的角色，全部未指定时返回 nil
+func BlendRoles(references []BlendReference) []string {
+	var roles []string
+	for i, reference := range references {
+		if reference.Role == "" {
+			continue
+		}
+		if roles == nil {
+			roles = make([]string, len(references))
+		}
+		roles[i] = reference.Role
+	}
+	return roles
+}
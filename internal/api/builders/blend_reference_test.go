@@ -0,0 +1,65 @@
+package builders
+
+import "testing"
+
+func TestBuildBlendAbilityListReferences(t *testing.T) {
+	references := []BlendReference{
+		{Role: BlendRoleSubject, Strength: 0.8},
+		{Role: BlendRoleStyle},
+		{},
+	}
+	list := BuildBlendAbilityList([]string{"uri-a", "uri-b", "uri-c"}, 0.5, references)
+	wants := []struct {
+		strength float64
+		role     string
+	}{
+		{strength: 0.8, role: BlendRoleSubject},
+		{strength: 0.5, role: BlendRoleStyle},
+		{strength: 0.5},
+	}
+	for i, want := range wants {
+		if got := list[i]["strength"]; got != want.strength {
+			t.Errorf("ability %d strength = %v, want %v", i, got, want.strength)
+		}
+		role, _ := list[i]["role"].(string)
+		if role != want.role {
+			t.Errorf("ability %d role = %q, want %q", i, role, want.role)
+		}
+	}
+
+	placeholders := BuildPromptPlaceholderList(len(list), BlendRoles(references))
+	for i, want := range wants {
+		role, _ := placeholders[i]["role"].(string)
+		if role != want.role || placeholders[i]["ability_index"] != i {
+			t.Errorf("placeholder %d = %v, want role %q", i, placeholders[i], want.role)
+		}
+	}
+}
+
+func TestBlendReferenceValidate(t *testing.T) {
+	tests := []struct {
+		reference BlendReference
+		wantErr   bool
+	}{
+		{reference: BlendReference{}},
+		{reference: BlendReference{Role: BlendRoleBackground, Strength: 1}},
+		{reference: BlendReference{Role: "foreground"}, wantErr: true},
+		{reference: BlendReference{Strength: 1.2}, wantErr: true},
+		{reference: BlendReference{Strength: -0.1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := tt.reference.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%+v) error = %v, wantErr %v", tt.reference, err, tt.wantErr)
+		}
+	}
+}
+
+func TestBlendRoles(t *testing.T) {
+	if roles := BlendRoles([]BlendReference{{Strength: 0.3}, {}}); roles != nil {
+		t.Errorf("BlendRoles() = %v, want nil", roles)
+	}
+	roles := BlendRoles([]BlendReference{{}, {Role: BlendRoleStyle}})
+	if len(roles) != 2 || roles[0] != "" || roles[1] != BlendRoleStyle {
+		t.Errorf("BlendRoles() = %v", roles)
+	}
+}
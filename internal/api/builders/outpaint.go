@@ -0,0 +1,92 @@
+package builders
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/utils"
+)
+
+// maxOutpaintScale 扩图后每条边最多为原图的倍数
+const maxOutpaintScale = 3
+
+// OutpaintPadding 扩图时原图各边向外扩展的像素数
+type OutpaintPadding struct {
+	Top    int `json:"top"`
+	Bottom int `json:"bottom"`
+	Left   int `json:"left"`
+	Right  int `json:"right"`
+}
+
+// Expand 返回扩展后的画布尺寸
+func (p OutpaintPadding) Expand(width, height int) (int, int) {
+	return width + p.Left + p.Right, height + p.Top + p.Bottom
+}
+
+// Validate 检查扩展像素非负、至少扩展一边且不超过原图的 maxOutpaintScale 倍
+func (p OutpaintPadding) Validate(width, height int) error {
+	if p.Top < 0 || p.Bottom < 0 || p.Left < 0 || p.Right < 0 {
+		return fmt.Errorf("padding 不能为负数")
+	}
+	if p.Top+p.Bottom+p.Left+p.Right == 0 {
+		return fmt.Errorf("至少需要向一个方向扩展")
+	}
+	w, h := p.Expand(width, height)
+	if w > width*maxOutpaintScale || h > height*maxOutpaintScale {
+		return fmt.Errorf("扩展后尺寸 %dx%d 超过原图 %dx%d 的 %d 倍", w, h, width, height, maxOutpaintScale)
+	}
+	return nil
+}
+
+// PaddingForRatio 将原图居中放到目标比例的画布上，返回各边需要扩展的像素
+func PaddingForRatio(width, height int, ratio string) (OutpaintPadding, error) {
+	parts := strings.SplitN(strings.TrimSpace(ratio), ":", 2)
+	if len(parts) != 2 {
+		return OutpaintPadding{}, fmt.Errorf("ratio 格式无效 \"%s\"，应为 宽:高，如 16:9", ratio)
+	}
+	rw, errW := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	rh, errH := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if errW != nil || errH != nil || rw <= 0 || rh <= 0 {
+		return OutpaintPadding{}, fmt.Errorf("ratio 格式无效 \"%s\"，应为 宽:高，如 16:9", ratio)
+	}
+
+	var padding OutpaintPadding
+	target := rw / rh
+	if target > float64(width)/float64(height) {
+		extra := int(math.Round(float64(height)*target)) - width
+		padding.Left = extra / 2
+		padding.Right = extra - padding.Left
+	} else {
+		extra := int(math.Round(float64(width)/target)) - height
+		padding.Top = extra / 2
+		padding.Bottom = extra - padding.Top
+	}
+	if err := padding.Validate(width, height); err != nil {
+		return OutpaintPadding{}, fmt.Errorf("ratio %s: %v", ratio, err)
+	}
+	return padding, nil
+}
+
+// BuildOutpaintAbilityList 构建扩图能力，各边扩展量按原图宽高的比例提交
+func BuildOutpaintAbilityList(imageID string, width, height int, padding OutpaintPadding, strength float64) []map[string]interface{} {
+	return []map[string]interface{}{
+		{
+			"type":           "",
+			"id":             utils.UUID(true),
+			"name":           "outpainting",
+			"image_uri_list": []string{imageID},
+			"image_list":     []map[string]interface{}{buildUploadedImage(imageID)},
+			"expand_param": map[string]interface{}{
+				"type":   "",
+				"id":     utils.UUID(true),
+				"top":    float64(padding.Top) / float64(height),
+				"bottom": float64(padding.Bottom) / float64(height),
+				"left":   float64(padding.Left) / float64(width),
+				"right":  float64(padding.Right) / float64(width),
+			},
+			"strength": strength,
+		},
+	}
+}
@@ -0,0 +1,52 @@
+package builders
+
+import "testing"
+
+func TestPaddingForRatio(t *testing.T) {
+	tests := []struct {
+		name    string
+		width   int
+		height  int
+		ratio   string
+		want    OutpaintPadding
+		wantErr bool
+	}{
+		{name: "square to 16:9", width: 1024, height: 1024, ratio: "16:9", want: OutpaintPadding{Left: 398, Right: 398}},
+		{name: "square to 9:16", width: 1024, height: 1024, ratio: "9:16", want: OutpaintPadding{Top: 398, Bottom: 398}},
+		{name: "odd extra goes to the far side", width: 1000, height: 1000, ratio: "4:3", want: OutpaintPadding{Left: 166, Right: 167}},
+		{name: "landscape to square", width: 1600, height: 900, ratio: "1:1", want: OutpaintPadding{Top: 350, Bottom: 350}},
+		{name: "same ratio", width: 1024, height: 1024, ratio: "1:1", wantErr: true},
+		{name: "too wide", width: 1024, height: 1024, ratio: "21:4", wantErr: true},
+		{name: "invalid ratio", width: 1024, height: 1024, ratio: "wide", wantErr: true},
+		{name: "zero ratio", width: 1024, height: 1024, ratio: "0:1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PaddingForRatio(tt.width, tt.height, tt.ratio)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PaddingForRatio() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("PaddingForRatio() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOutpaintPaddingValidate(t *testing.T) {
+	tests := []struct {
+		padding OutpaintPadding
+		wantErr bool
+	}{
+		{padding: OutpaintPadding{Left: 100}},
+		{padding: OutpaintPadding{Top: 1024, Bottom: 1024}},
+		{padding: OutpaintPadding{}, wantErr: true},
+		{padding: OutpaintPadding{Left: -1, Right: 10}, wantErr: true},
+		{padding: OutpaintPadding{Top: 1024, Bottom: 1025}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := tt.padding.Validate(1024, 1024); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%+v) error = %v, wantErr %v", tt.padding, err, tt.wantErr)
+		}
+	}
+}
@@ -393,35 +393,86 @@ func BuildGenerateRequest(options BuildGenerateRequestOptions) map[string]interf
 	}
 }
 
-func BuildBlendAbilityList(uploadedImageIds []string, strength float64) []map[string]interface{} {
+// BuildBlendAbilityList 构建图生图能力列表，每张图片一个能力；references 与图片一一对应，可为空
+// 参考图未指定强度时使用全局 strength，指定了角色时写入 role
+func BuildBlendAbilityList(uploadedImageIds []string, strength float64, references []BlendReference) []map[string]interface{} {
 	list := make([]map[string]interface{}, len(uploadedImageIds))
 	for i, imageID := range uploadedImageIds {
-		list[i] = map[string]interface{}{
+		ability := map[string]interface{}{
 			"type":           "",
 			"id":             utils.UUID(true),
 			"name":           "byte_edit",
 			"image_uri_list": []string{imageID},
-			"image_list": []map[string]interface{}{
-				{
-					"type":          "image",
-					"id":            utils.UUID(true),
-					"source_from":   "upload",
-					"platform_type": 1,
-					"name":          "",
-					"image_uri":     imageID,
-					"width":         0,
-					"height":        0,
-					"format":        "",
-					"uri":           imageID,
-				},
-			},
-			"strength": strength,
+			"image_list":     []map[string]interface{}{buildUploadedImage(imageID)},
+			"strength":       strength,
+		}
+		if i < len(references) {
+			if references[i].Strength > 0 {
+				ability["strength"] = references[i].Strength
+			}
+			if references[i].Role != "" {
+				ability["role"] = references[i].Role
+			}
 		}
+		list[i] = ability
 	}
 	return list
 }
 
-func BuildPromptPlaceholderList(count int) []map[string]interface{} {
+// BuildInpaintAbilityList 构建局部重绘能力，原图和遮罩（白色为重绘区域）作为同一个能力提交，只重绘遮罩区域
+func BuildInpaintAbilityList(imageID, maskID string, strength float64) []map[string]interface{} {
+	return []map[string]interface{}{
+		{
+			"type":           "",
+			"id":             utils.UUID(true),
+			"name":           "inpaint",
+			"image_uri_list": []string{imageID},
+			"image_list":     []map[string]interface{}{buildUploadedImage(imageID)},
+			"mask_uri":       maskID,
+			"mask":           buildUploadedImage(maskID),
+			"strength":       strength,
+		},
+	}
+}
+
+// BuildSuperResolutionAbilityList 构建超分能力，将原图放大到目标分辨率
+func BuildSuperResolutionAbilityList(imageID string, resolution *ResolutionResult, strength float64) []map[string]interface{} {
+	return []map[string]interface{}{
+		{
+			"type":           "",
+			"id":             utils.UUID(true),
+			"name":           "super_resolution",
+			"image_uri_list": []string{imageID},
+			"image_list":     []map[string]interface{}{buildUploadedImage(imageID)},
+			"super_resolution_param": map[string]interface{}{
+				"type":            "",
+				"id":              utils.UUID(true),
+				"resolution_type": resolution.ResolutionType,
+				"width":           resolution.Width,
+				"height":          resolution.Height,
+			},
+			"strength": strength,
+		},
+	}
+}
+
+func buildUploadedImage(imageID string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":          "image",
+		"id":            utils.UUID(true),
+		"source_from":   "upload",
+		"platform_type": 1,
+		"name":          "",
+		"image_uri":     imageID,
+		"width":         0,
+		"height":        0,
+		"format":        "",
+		"uri":           imageID,
+	}
+}
+
+// BuildPromptPlaceholderList 构建提示词占位列表，每个能力一项；roles 与能力一一对应，非空时写入 role
+func BuildPromptPlaceholderList(count int, roles []string) []map[string]interface{} {
 	list := make([]map[string]interface{}, count)
 	for i := 0; i < count; i++ {
 		list[i] = map[string]interface{}{
@@ -429,6 +480,9 @@ func BuildPromptPlaceholderList(count int) []map[string]interface{} {
 			"id":            utils.UUID(true),
 			"ability_index": i,
 		}
+		if i < len(roles) && roles[i] != "" {
+			list[i]["role"] = roles[i]
+		}
 	}
 	return list
 }
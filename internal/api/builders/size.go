@@ -0,0 +1,90 @@
+package builders
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gloryhry/jimeng-api-go/internal/api/consts"
+)
+
+// SizeMatch 与请求尺寸最接近的预设比例和分辨率
+type SizeMatch struct {
+	Resolution string
+	Ratio      string
+	Width      int
+	Height     int
+}
+
+// ParseSize 解析 OpenAI 风格的 "WxH" 尺寸
+func ParseSize(size string) (int, int, error) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(size)), "x")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("size 格式无效 \"%s\"，应为 宽x高，如 1024x1024", size)
+	}
+	width, errW := strconv.Atoi(strings.TrimSpace(parts[0]))
+	height, errH := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if errW != nil || errH != nil || width <= 0 || height <= 0 {
+		return 0, 0, fmt.Errorf("size 格式无效 \"%s\"，应为 宽x高，如 1024x1024", size)
+	}
+	return width, height, nil
+}
+
+// NearestSize 先按宽高比选出最接近的比例，再在该比例下按像素数选出最接近的分辨率
+// 宽高比和像素数都在对数空间比较，放大和缩小同等对待
+func NearestSize(width, height int) SizeMatch {
+	aspect := math.Log(float64(width) / float64(height))
+	pixels := math.Log(float64(width) * float64(height))
+
+	ratios := sortedKeys(consts.ResolutionOptions["2k"])
+	bestRatio, bestRatioDiff := "", math.Inf(1)
+	for _, ratio := range ratios {
+		w, h := parseRatio(ratio)
+		if diff := math.Abs(aspect - math.Log(w/h)); diff < bestRatioDiff {
+			bestRatio, bestRatioDiff = ratio, diff
+		}
+	}
+
+	match := SizeMatch{Ratio: bestRatio}
+	bestPixelDiff := math.Inf(1)
+	for _, resolution := range sortedKeys(consts.ResolutionOptions) {
+		params, ok := consts.ResolutionOptions[resolution][bestRatio]
+		if !ok {
+			continue
+		}
+		if diff := math.Abs(pixels - math.Log(float64(params.Width)*float64(params.Height))); diff < bestPixelDiff {
+			bestPixelDiff = diff
+			match.Resolution, match.Width, match.Height = resolution, params.Width, params.Height
+		}
+	}
+	return match
+}
+
+// ScaleToResolution 保持原图宽高比，将原图尺寸缩放到与 target 相同的像素数，分辨率档位沿用 target
+func ScaleToResolution(width, height int, target *ResolutionResult) *ResolutionResult {
+	scale := math.Sqrt(float64(target.Width) * float64(target.Height) / (float64(width) * float64(height)))
+	return &ResolutionResult{
+		Width:          int(math.Round(float64(width) * scale)),
+		Height:         int(math.Round(float64(height) * scale)),
+		ImageRatio:     float64(width) / float64(height),
+		ResolutionType: target.ResolutionType,
+	}
+}
+
+func parseRatio(ratio string) (float64, float64) {
+	parts := strings.SplitN(ratio, ":", 2)
+	w, _ := strconv.ParseFloat(parts[0], 64)
+	h, _ := strconv.ParseFloat(parts[1], 64)
+	return w, h
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,68 @@
+package builders
+
+import "testing"
+
+func TestNearestSize(t *testing.T) {
+	tests := []struct {
+		size           string
+		wantResolution string
+		wantRatio      string
+		wantWidth      int
+		wantHeight     int
+	}{
+		{size: "1024x1024", wantResolution: "1k", wantRatio: "1:1", wantWidth: 1328, wantHeight: 1328},
+		{size: "2048x2048", wantResolution: "2k", wantRatio: "1:1", wantWidth: 2048, wantHeight: 2048},
+		{size: "1792x1024", wantResolution: "1k", wantRatio: "16:9", wantWidth: 1664, wantHeight: 936},
+		{size: "1024x1792", wantResolution: "1k", wantRatio: "9:16", wantWidth: 936, wantHeight: 1664},
+		{size: "2560x1440", wantResolution: "2k", wantRatio: "16:9", wantWidth: 2560, wantHeight: 1440},
+		{size: "1536x1024", wantResolution: "1k", wantRatio: "3:2", wantWidth: 1584, wantHeight: 1056},
+		{size: "1024x1536", wantResolution: "1k", wantRatio: "2:3", wantWidth: 1056, wantHeight: 1584},
+		{size: "3840x2160", wantResolution: "4k", wantRatio: "16:9", wantWidth: 5404, wantHeight: 3040},
+		{size: "2560x1080", wantResolution: "2k", wantRatio: "21:9", wantWidth: 3024, wantHeight: 1296},
+		{size: "800x600", wantResolution: "1k", wantRatio: "4:3", wantWidth: 1472, wantHeight: 1104},
+		{size: "256x256", wantResolution: "1k", wantRatio: "1:1", wantWidth: 1328, wantHeight: 1328},
+	}
+	for _, tt := range tests {
+		t.Run(tt.size, func(t *testing.T) {
+			width, height, err := ParseSize(tt.size)
+			if err != nil {
+				t.Fatalf("ParseSize() error = %v", err)
+			}
+			got := NearestSize(width, height)
+			want := SizeMatch{Resolution: tt.wantResolution, Ratio: tt.wantRatio, Width: tt.wantWidth, Height: tt.wantHeight}
+			if got != want {
+				t.Errorf("NearestSize(%d, %d) = %+v, want %+v", width, height, got, want)
+			}
+		})
+	}
+}
+
+func TestParseSizeInvalid(t *testing.T) {
+	for _, size := range []string{"", "auto", "1024", "1024x", "0x1024", "-1x5", "axb", "1x2x3"} {
+		if _, _, err := ParseSize(size); err == nil {
+			t.Errorf("ParseSize(%q) should fail", size)
+		}
+	}
+}
+
+func TestScaleToResolution(t *testing.T) {
+	target := &ResolutionResult{Width: 4096, Height: 4096, ResolutionType: "4k"}
+	tests := []struct {
+		name          string
+		width, height int
+		wantWidth     int
+		wantHeight    int
+	}{
+		{name: "square", width: 1024, height: 1024, wantWidth: 4096, wantHeight: 4096},
+		{name: "uncommon aspect", width: 1000, height: 500, wantWidth: 5793, wantHeight: 2896},
+		{name: "portrait", width: 900, height: 1600, wantWidth: 3072, wantHeight: 5461},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ScaleToResolution(tt.width, tt.height, target)
+			if got.Width != tt.wantWidth || got.Height != tt.wantHeight || got.ResolutionType != "4k" {
+				t.Errorf("ScaleToResolution() = %dx%d@%s, want %dx%d@4k", got.Width, got.Height, got.ResolutionType, tt.wantWidth, tt.wantHeight)
+			}
+		})
+	}
+}
@@ -40,10 +40,35 @@ func (p chatModelPayload) DisplayModel() string {
 	return p.Model
 }
 
-// CreateCompletion 同步补全
-func CreateCompletion(messages []ChatMessage, refreshToken string, model string) (map[string]interface{}, error) {
+// ChatOutcome 补全实际的生成结果，供审计记录；流式补全在关闭通道前写入，需等通道关闭后再读取
+type ChatOutcome struct {
+	HistoryID string
+	URLs      []string
+	Err       error
+}
+
+func (o *ChatOutcome) recordImages(result *ImageResult, err error) {
+	o.HistoryID, o.URLs, o.Err = "", nil, err
+	if result != nil {
+		o.HistoryID, o.URLs = result.HistoryID, result.URLs
+	}
+}
+
+func (o *ChatOutcome) recordVideo(result *VideoResult, err error) {
+	o.HistoryID, o.URLs, o.Err = "", nil, err
+	if result != nil {
+		o.HistoryID = result.HistoryID
+		if result.URL != "" {
+			o.URLs = []string{result.URL}
+		}
+	}
+}
+
+// CreateCompletion 同步补全，视频生成失败时返回说明文字而不是错误，实际结果见 ChatOutcome
+func CreateCompletion(messages []ChatMessage, refreshToken string, model string) (map[string]interface{}, *ChatOutcome, error) {
+	outcome := &ChatOutcome{}
 	if len(messages) == 0 {
-		return nil, errors.ErrAPIRequestParamsInvalid("消息不能为空")
+		return nil, outcome, errors.ErrAPIRequestParamsInvalid("消息不能为空")
 	}
 	if model == "" {
 		model = defaultChatModel
@@ -51,13 +76,14 @@ func CreateCompletion(messages []ChatMessage, refreshToken string, model string)
 	payload := parseChatModel(model)
 	logger.Info(fmt.Sprintf("Chat completion messages: %+v", messages))
 	prompt := strings.TrimSpace(messages[len(messages)-1].Content)
-	return createCompletionWithRetry(payload, prompt, refreshToken, 0)
+	response, err := createCompletionWithRetry(payload, prompt, refreshToken, outcome, 0)
+	return response, outcome, err
 }
 
-// CreateCompletionStream 流式补全
-func CreateCompletionStream(messages []ChatMessage, refreshToken string, model string) (chan string, error) {
+// CreateCompletionStream 流式补全，生成结果在通道关闭前写入返回的 ChatOutcome
+func CreateCompletionStream(messages []ChatMessage, refreshToken string, model string) (chan string, *ChatOutcome, error) {
 	if len(messages) == 0 {
-		return nil, errors.ErrAPIRequestParamsInvalid("消息不能为空")
+		return nil, nil, errors.ErrAPIRequestParamsInvalid("消息不能为空")
 	}
 	if model == "" {
 		model = defaultChatModel
@@ -67,41 +93,43 @@ func CreateCompletionStream(messages []ChatMessage, refreshToken string, model s
 	prompt := strings.TrimSpace(messages[len(messages)-1].Content)
 
 	stream := make(chan string, 8)
+	outcome := &ChatOutcome{}
 	go func() {
 		defer close(stream)
 		if isVideoModel(payload.Original) {
-			streamVideoCompletion(stream, payload, prompt, refreshToken)
+			streamVideoCompletion(stream, payload, prompt, refreshToken, outcome)
 		} else {
-			streamImageCompletion(stream, payload, prompt, refreshToken)
+			streamImageCompletion(stream, payload, prompt, refreshToken, outcome)
 		}
 	}()
-	return stream, nil
+	return stream, outcome, nil
 }
 
-func createCompletionWithRetry(payload chatModelPayload, prompt string, refreshToken string, attempt int) (map[string]interface{}, error) {
-	response, err := createCompletionOnce(payload, prompt, refreshToken)
+func createCompletionWithRetry(payload chatModelPayload, prompt string, refreshToken string, outcome *ChatOutcome, attempt int) (map[string]interface{}, error) {
+	response, err := createCompletionOnce(payload, prompt, refreshToken, outcome)
 	if err == nil {
 		return response, nil
 	}
 	if attempt < consts.MaxRetryCount {
 		logger.Warn(fmt.Sprintf("聊天补全失败 (尝试 %d/%d): %v", attempt+1, consts.MaxRetryCount+1, err))
 		time.Sleep(time.Duration(consts.RetryDelay) * time.Millisecond)
-		return createCompletionWithRetry(payload, prompt, refreshToken, attempt+1)
+		return createCompletionWithRetry(payload, prompt, refreshToken, outcome, attempt+1)
 	}
 	return nil, err
 }
 
-func createCompletionOnce(payload chatModelPayload, prompt string, refreshToken string) (map[string]interface{}, error) {
+func createCompletionOnce(payload chatModelPayload, prompt string, refreshToken string, outcome *ChatOutcome) (map[string]interface{}, error) {
 	if isVideoModel(payload.Original) {
 		modelName := payload.Original
 		if strings.TrimSpace(modelName) == "" {
 			modelName = payload.Model
 		}
-		videoURL, err := GenerateVideo(modelName, prompt, &VideoOptions{
+		video, err := GenerateVideo(modelName, prompt, &VideoOptions{
 			Ratio:      "1:1",
 			Resolution: "720p",
 			Duration:   5,
 		}, refreshToken)
+		outcome.recordVideo(video, err)
 		if err != nil {
 			if _, ok := err.(*errors.APIException); ok {
 				return nil, err
@@ -109,27 +137,29 @@ func createCompletionOnce(payload chatModelPayload, prompt string, refreshToken
 			message := fmt.Sprintf("生成视频失败: %v\n\n如果您在即梦官网看到已生成的视频，可能是获取结果时出现了问题，请前往即梦官网查看。", err)
 			return chatResponse(payload.DisplayModel(), message), nil
 		}
-		return chatResponse(payload.DisplayModel(), fmt.Sprintf("![video](%s)\n", videoURL)), nil
+		return chatResponse(payload.DisplayModel(), fmt.Sprintf("![video](%s)\n", video.URL)), nil
 	}
 
-	images, err := GenerateImages(payload.Model, prompt, &ImageOptions{}, refreshToken)
+	result, err := GenerateImages(payload.Model, prompt, &ImageOptions{}, refreshToken)
+	outcome.recordImages(result, err)
 	if err != nil {
 		return nil, err
 	}
 	var message strings.Builder
-	for idx, url := range images {
+	for idx, url := range result.URLs {
 		message.WriteString(fmt.Sprintf("![image_%d](%s)\n", idx, url))
 	}
 	return chatResponse(payload.DisplayModel(), message.String()), nil
 }
 
-func streamImageCompletion(stream chan<- string, payload chatModelPayload, prompt string, refreshToken string) {
+func streamImageCompletion(stream chan<- string, payload chatModelPayload, prompt string, refreshToken string, outcome *ChatOutcome) {
 	done := make(chan struct{})
 	defer close(done)
 
 	sendStreamChunk(stream, done, buildChunk(payload.DisplayModel(), 0, "assistant", "🎨 图像生成中，请稍候...", nil))
 
-	images, err := GenerateImages(payload.Model, prompt, &ImageOptions{}, refreshToken)
+	result, err := GenerateImages(payload.Model, prompt, &ImageOptions{}, refreshToken)
+	outcome.recordImages(result, err)
 	if err != nil {
 		logger.Error(fmt.Sprintf("图像生成失败: %v", err))
 		sendStreamChunk(stream, done, buildChunk(payload.DisplayModel(), 1, "assistant", fmt.Sprintf("生成图片失败: %v", err), "stop"))
@@ -137,6 +167,7 @@ func streamImageCompletion(stream chan<- string, payload chatModelPayload, promp
 		return
 	}
 
+	images := result.URLs
 	for idx, url := range images {
 		finish := interface{}(nil)
 		if idx == len(images)-1 {
@@ -149,7 +180,7 @@ func streamImageCompletion(stream chan<- string, payload chatModelPayload, promp
 	sendStreamDone(stream, done)
 }
 
-func streamVideoCompletion(stream chan<- string, payload chatModelPayload, prompt string, refreshToken string) {
+func streamVideoCompletion(stream chan<- string, payload chatModelPayload, prompt string, refreshToken string, outcome *ChatOutcome) {
 	done := make(chan struct{})
 	defer close(done)
 
@@ -184,11 +215,12 @@ func streamVideoCompletion(stream chan<- string, payload chatModelPayload, promp
 		modelName = payload.Model
 	}
 
-	videoURL, err := GenerateVideo(modelName, prompt, &VideoOptions{
+	video, err := GenerateVideo(modelName, prompt, &VideoOptions{
 		Ratio:      "1:1",
 		Resolution: "720p",
 		Duration:   5,
 	}, refreshToken)
+	outcome.recordVideo(video, err)
 	if err != nil {
 		logger.Error(fmt.Sprintf("视频生成失败: %v", err))
 		errorMessage := formatVideoErrorMessage(err)
@@ -197,7 +229,7 @@ func streamVideoCompletion(stream chan<- string, payload chatModelPayload, promp
 		return
 	}
 
-	success := fmt.Sprintf("\n\n✅ 视频生成完成！\n\n![video](%s)\n\n您可以：\n1. 直接查看上方视频\n2. 使用以下链接下载或分享：%s", videoURL, videoURL)
+	success := fmt.Sprintf("\n\n✅ 视频生成完成！\n\n![video](%s)\n\n您可以：\n1. 直接查看上方视频\n2. 使用以下链接下载或分享：%s", video.URL, video.URL)
 	sendStreamChunk(stream, done, buildChunk(displayModel, 1, "assistant", success, nil))
 	sendStreamChunk(stream, done, buildChunk(displayModel, 2, "assistant", "", "stop"))
 	sendStreamDone(stream, done)
@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestChatOutcome(t *testing.T) {
+	failed := errors.New("failed")
+	tests := []struct {
+		name   string
+		record func(o *ChatOutcome)
+		want   ChatOutcome
+	}{
+		{
+			name:   "images",
+			record: func(o *ChatOutcome) { o.recordImages(&ImageResult{HistoryID: "h1", URLs: []string{"a", "b"}}, nil) },
+			want:   ChatOutcome{HistoryID: "h1", URLs: []string{"a", "b"}},
+		},
+		{
+			name:   "video submitted but polling failed",
+			record: func(o *ChatOutcome) { o.recordVideo(&VideoResult{HistoryID: "h2"}, failed) },
+			want:   ChatOutcome{HistoryID: "h2", Err: failed},
+		},
+		{
+			name: "retry overwrites earlier failure",
+			record: func(o *ChatOutcome) {
+				o.recordImages(nil, failed)
+				o.recordVideo(&VideoResult{HistoryID: "h3", URL: "v"}, nil)
+			},
+			want: ChatOutcome{HistoryID: "h3", URLs: []string{"v"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := &ChatOutcome{}
+			tt.record(got)
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("outcome = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
@@ -1,17 +1,10 @@
 package controllers
 
 import (
-	"bytes"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"io"
 	"math/rand"
-	"mime"
-	"mime/multipart"
-	"net/http"
 	"net/url"
-	"path/filepath"
 	"strings"
 	"time"
 
@@ -28,8 +21,6 @@ var (
 	UserID   = utils.UUID(false)
 )
 
-const fileMaxSize = 100 * 1024 * 1024
-
 // 伪装 headers
 var FakeHeaders = map[string]string{
 	"Accept":             "application/json, text/plain, */*",
@@ -70,12 +61,6 @@ type CreditInfo struct {
 	TotalCredit    int64 `json:"total_credit"`
 }
 
-// UploadFileResult 上传文件结果
-type UploadFileResult struct {
-	ImageURI string `json:"image_uri"`
-	URI      string `json:"uri"`
-}
-
 // AcquireToken 去除地区前缀
 func AcquireToken(refreshToken string) string {
 	return utils.RemoveRegionPrefix(refreshToken)
@@ -366,189 +351,6 @@ func ReceiveCredit(refreshToken string) (int64, error) {
 	return cur, nil
 }
 
-// CheckFileURL 校验文件 URL 是否可用并限制大小
-func CheckFileURL(fileURL string) error {
-	if fileURL == "" {
-		return errors.ErrAPIRequestParamsInvalid("File URL 不能为空")
-	}
-	if utils.IsBASE64(fileURL) || utils.IsDataURI(fileURL) {
-		return nil
-	}
-
-	req, err := http.NewRequest("HEAD", fileURL, nil)
-	if err != nil {
-		return errors.ErrAPIRequestFailed(fmt.Sprintf("构建请求失败: %v", err))
-	}
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36")
-	req.Header.Set("Accept", "*/*")
-	req.Header.Set("Accept-Language", "zh-CN,zh;q=0.9")
-
-	client := &http.Client{Timeout: 15 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return errors.ErrAPIRequestFailed(fmt.Sprintf("文件 %s 不可访问: %v", fileURL, err))
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		return errors.ErrAPIRequestFailed(fmt.Sprintf("文件 %s 无效: [%d] %s", fileURL, resp.StatusCode, resp.Status))
-	}
-	if resp.ContentLength > 0 && resp.ContentLength > fileMaxSize {
-		return errors.ErrAPIRequestFailed(fmt.Sprintf("文件 %s 超过大小限制(>100MB)", fileURL))
-	}
-	return nil
-}
-
-// UploadFile 上传远程或本地文件
-func UploadFile(refreshToken string, fileURL string, isVideoImage bool) (*UploadFileResult, error) {
-	logger.Info(fmt.Sprintf("开始上传文件: %s, 视频图像模式: %v", fileURL, isVideoImage))
-	if err := CheckFileURL(fileURL); err != nil {
-		return nil, err
-	}
-
-	var (
-		filename string
-		fileData []byte
-		mimeType string
-		err      error
-	)
-
-	switch {
-	case utils.IsDataURI(fileURL):
-		mimeType = utils.ExtractDataURIMimeType(fileURL)
-		ext := utils.GuessFileExtension(mimeType)
-		filename = fmt.Sprintf("%s.%s", utils.UUID(false), ext)
-		decoded, decodeErr := base64.StdEncoding.DecodeString(utils.RemoveDataURIHeader(fileURL))
-		if decodeErr != nil {
-			return nil, errors.ErrAPIRequestFailed(fmt.Sprintf("解析BASE64数据失败: %v", decodeErr))
-		}
-		fileData = decoded
-	case utils.IsBASE64(fileURL):
-		filename = fmt.Sprintf("%s.bin", utils.UUID(false))
-		data, decodeErr := base64.StdEncoding.DecodeString(fileURL)
-		if decodeErr != nil {
-			return nil, errors.ErrAPIRequestFailed(fmt.Sprintf("解析BASE64失败: %v", decodeErr))
-		}
-		fileData = data
-		mimeType = "application/octet-stream"
-	default:
-		filename = filepath.Base(fileURL)
-		if filename == "" {
-			filename = fmt.Sprintf("%s.bin", utils.UUID(false))
-		}
-		req, reqErr := http.NewRequest("GET", fileURL, nil)
-		if reqErr != nil {
-			return nil, errors.ErrAPIRequestFailed(fmt.Sprintf("构建下载请求失败: %v", reqErr))
-		}
-		req.Header.Set("User-Agent", FakeHeaders["User-Agent"])
-		req.Header.Set("Accept", "*/*")
-		req.Header.Set("Accept-Language", "zh-CN,zh;q=0.9")
-
-		client := &http.Client{Timeout: 60 * time.Second}
-		resp, respErr := client.Do(req)
-		if respErr != nil {
-			return nil, errors.ErrAPIRequestFailed(fmt.Sprintf("下载文件失败: %v", respErr))
-		}
-		defer resp.Body.Close()
-		if resp.StatusCode >= 400 {
-			return nil, errors.ErrAPIRequestFailed(fmt.Sprintf("下载文件失败: [%d] %s", resp.StatusCode, resp.Status))
-		}
-		fileData, err = io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, errors.ErrAPIRequestFailed(fmt.Sprintf("读取文件失败: %v", err))
-		}
-		if resp.ContentLength > 0 && resp.ContentLength > fileMaxSize {
-			return nil, errors.ErrAPIRequestFailed("文件大小超过限制(>100MB)")
-		}
-	}
-
-	if len(fileData) == 0 {
-		return nil, errors.ErrAPIRequestFailed("文件内容为空")
-	}
-	if mimeType == "" {
-		if ext := strings.ToLower(filepath.Ext(filename)); ext != "" {
-			mimeType = mime.TypeByExtension(ext)
-		}
-		if mimeType == "" && len(fileData) >= 512 {
-			mimeType = http.DetectContentType(fileData[:512])
-		}
-		if mimeType == "" {
-			mimeType = "application/octet-stream"
-		}
-	}
-
-	scene := "aigc_image"
-	if isVideoImage {
-		scene = "video_cover"
-	}
-	proofRequest := map[string]interface{}{
-		"scene":     scene,
-		"file_name": filename,
-		"file_size": len(fileData),
-	}
-
-	proofResult, err := Request("POST", "/mweb/v1/get_upload_image_proof", refreshToken, &RequestOptions{Body: proofRequest})
-	if err != nil {
-		return nil, err
-	}
-	proofInfo := mapValue(proofResult, "proof_info")
-	if len(proofInfo) == 0 {
-		return nil, errors.ErrAPIRequestFailed("获取上传凭证失败")
-	}
-	imageURI := fmt.Sprintf("%v", proofInfo["image_uri"])
-	if imageURI == "" {
-		return nil, errors.ErrAPIRequestFailed("上传凭证缺少 image_uri")
-	}
-
-	headerMap := mapStringMap(proofInfo["headers"])
-	queryMap := mapStringMap(proofInfo["query_params"])
-
-	var buffer bytes.Buffer
-	writer := multipart.NewWriter(&buffer)
-	part, err := writer.CreateFormFile("file", filename)
-	if err != nil {
-		return nil, errors.ErrAPIRequestFailed(fmt.Sprintf("创建表单失败: %v", err))
-	}
-	if _, err := part.Write(fileData); err != nil {
-		return nil, errors.ErrAPIRequestFailed(fmt.Sprintf("写入文件失败: %v", err))
-	}
-	writer.Close()
-
-	uploadURL := "https://imagex.bytedanceapi.com/"
-	req, err := http.NewRequest("POST", uploadURL, &buffer)
-	if err != nil {
-		return nil, errors.ErrAPIRequestFailed(fmt.Sprintf("构建上传请求失败: %v", err))
-	}
-	req.Header.Set("Content-Type", writer.FormDataContentType())
-	for k, v := range headerMap {
-		if strings.EqualFold(k, "Content-Type") {
-			req.Header.Set(k, writer.FormDataContentType())
-			continue
-		}
-		req.Header.Set(k, v)
-	}
-	query := req.URL.Query()
-	for k, v := range queryMap {
-		query.Set(k, v)
-	}
-	req.URL.RawQuery = query.Encode()
-
-	client := &http.Client{Timeout: 60 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, errors.ErrFileUploadFailed(fmt.Sprintf("上传文件失败: %v", err))
-	}
-	defer resp.Body.Close()
-
-	body, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode >= 400 {
-		return nil, errors.ErrFileUploadFailed(fmt.Sprintf("上传文件失败: 状态码 %d, 响应: %s", resp.StatusCode, string(body)))
-	}
-
-	logger.Info(fmt.Sprintf("文件上传成功: %s", imageURI))
-	return &UploadFileResult{ImageURI: imageURI, URI: imageURI}, nil
-}
-
 // GetTokenLiveStatus 校验 token
 func GetTokenLiveStatus(refreshToken string) (bool, error) {
 	_, err := Request("POST", "/passport/account/info/v2", refreshToken, &RequestOptions{
@@ -583,13 +385,3 @@ func mapValue(v interface{}, key string) map[string]interface{} {
 	}
 	return map[string]interface{}{}
 }
-
-func mapStringMap(value interface{}) map[string]string {
-	result := map[string]string{}
-	if m, ok := value.(map[string]interface{}); ok {
-		for k, v := range m {
-			result[k] = fmt.Sprintf("%v", v)
-		}
-	}
-	return result
-}
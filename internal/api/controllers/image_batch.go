@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/config"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/errors"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/logger"
+)
+
+// 单次生成预计返回的图片数量，用于估算需要提交的任务数
+const (
+	ImagesPerGeneration  = 4
+	ImagesPerComposition = 1
+)
+
+// maxCollectRounds 提交轮数上限，实际返回数量少于预期时按实际产出补交
+const maxCollectRounds = 3
+
+// CollectImages 并发提交多次生成直到收集到 n 张图片，tokens 轮流使用
+// 部分提交失败时返回已收集的图片并在 Failures 中记录原因，全部失败时返回第一个错误
+func CollectImages(n int, perSubmission int, tokens []string, generate func(token string) (*ImageResult, error)) (*ImageResult, error) {
+	if len(tokens) == 0 {
+		return nil, errors.ErrAPIRequestParamsInvalid("缺少 token")
+	}
+	if perSubmission <= 0 {
+		perSubmission = 1
+	}
+	limit := config.System.ImageConcurrency
+	if limit <= 0 {
+		limit = 1
+	}
+
+	collected := &ImageResult{Requested: n}
+	var firstErr error
+	submitted := 0
+	yield := perSubmission
+	for round := 0; len(collected.URLs) < n && round < maxCollectRounds; round++ {
+		count := (n - len(collected.URLs) + yield - 1) / yield
+		logger.Info(fmt.Sprintf("第 %d 轮提交 %d 个生成任务，目标 %d 张，已收集 %d 张", round+1, count, n, len(collected.URLs)))
+
+		results := make([]*ImageResult, count)
+		errs := make([]error, count)
+		semaphore := make(chan struct{}, limit)
+		var wg sync.WaitGroup
+		for i := 0; i < count; i++ {
+			token := tokens[(submitted+i)%len(tokens)]
+			wg.Add(1)
+			go func(i int, token string) {
+				defer wg.Done()
+				semaphore <- struct{}{}
+				defer func() { <-semaphore }()
+				results[i], errs[i] = generate(token)
+			}(i, token)
+		}
+		wg.Wait()
+
+		succeeded, produced := 0, 0
+		for i := 0; i < count; i++ {
+			if errs[i] != nil {
+				if firstErr == nil {
+					firstErr = errs[i]
+				}
+				collected.Failures = append(collected.Failures, fmt.Sprintf("第 %d 个任务: %v", submitted+i+1, errs[i]))
+				continue
+			}
+			if results[i] == nil {
+				continue
+			}
+			succeeded++
+			produced += len(results[i].URLs)
+			if collected.Width == 0 {
+				collected.Width, collected.Height = results[i].Width, results[i].Height
+				collected.MultiImage = results[i].MultiImage
+			}
+			collected.HistoryIDs = append(collected.HistoryIDs, results[i].HistoryID)
+			collected.URLs = append(collected.URLs, results[i].URLs...)
+			for range results[i].URLs {
+				collected.Seeds = append(collected.Seeds, results[i].Seed)
+			}
+		}
+		submitted += count
+		if succeeded == 0 || produced == 0 {
+			break
+		}
+		yield = produced / succeeded
+		if yield <= 0 {
+			yield = 1
+		}
+	}
+
+	if len(collected.URLs) == 0 {
+		if firstErr != nil {
+			return nil, firstErr
+		}
+		return nil, errors.ErrAPIImageGenerationFailed("未生成任何图片")
+	}
+	if len(collected.URLs) > n {
+		collected.URLs = collected.URLs[:n]
+		collected.Seeds = collected.Seeds[:n]
+	}
+	collected.HistoryID = collected.HistoryIDs[0]
+	collected.Seed = collected.Seeds[0]
+	if collected.Partial() {
+		logger.Warn(fmt.Sprintf("仅收集到 %d/%d 张图片，失败任务 %d 个", len(collected.URLs), n, len(collected.Failures)))
+	}
+	return collected, nil
+}
@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/config"
+)
+
+func TestCollectImages(t *testing.T) {
+	previous := config.System
+	t.Cleanup(func() { config.System = previous })
+	config.System = &config.SystemConfig{ImageConcurrency: 2}
+
+	tests := []struct {
+		name        string
+		n           int
+		perBatch    int
+		produce     int // 每个任务返回的图片数量
+		failTokens  map[string]bool
+		wantURLs    int
+		wantPartial bool
+		wantErr     bool
+	}{
+		{name: "single batch", n: 3, perBatch: 4, produce: 4, wantURLs: 3},
+		{name: "multiple batches", n: 10, perBatch: 4, produce: 4, wantURLs: 10},
+		{name: "fewer images than expected", n: 4, perBatch: 4, produce: 1, wantURLs: 4},
+		{name: "failed submissions are resubmitted", n: 8, perBatch: 4, produce: 4, failTokens: map[string]bool{"b": true}, wantURLs: 8},
+		{name: "partial after max rounds", n: 16, perBatch: 4, produce: 1, failTokens: map[string]bool{"b": true}, wantURLs: 13, wantPartial: true},
+		{name: "all failed", n: 4, perBatch: 4, produce: 4, failTokens: map[string]bool{"a": true, "b": true}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			calls := 0
+			result, err := CollectImages(tt.n, tt.perBatch, []string{"a", "b"}, func(token string) (*ImageResult, error) {
+				mu.Lock()
+				calls++
+				id := calls
+				mu.Unlock()
+				if tt.failTokens[token] {
+					return nil, fmt.Errorf("token %s failed", token)
+				}
+				urls := make([]string, tt.produce)
+				for i := range urls {
+					urls[i] = fmt.Sprintf("https://example.com/%d/%d.png", id, i)
+				}
+				return &ImageResult{HistoryID: fmt.Sprint(id), URLs: urls, Seed: int64(id)}, nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CollectImages() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(result.URLs) != tt.wantURLs {
+				t.Errorf("len(URLs) = %d, want %d", len(result.URLs), tt.wantURLs)
+			}
+			if len(result.Seeds) != len(result.URLs) || result.Seed != result.Seeds[0] {
+				t.Errorf("Seeds = %v (Seed %d), want one seed per URL", result.Seeds, result.Seed)
+			}
+			if result.Partial() != tt.wantPartial {
+				t.Errorf("Partial() = %v, want %v (failures %v)", result.Partial(), tt.wantPartial, result.Failures)
+			}
+			if len(tt.failTokens) > 0 && len(result.Failures) == 0 {
+				t.Error("failures should be reported")
+			}
+		})
+	}
+}
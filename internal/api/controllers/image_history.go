@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/errors"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/utils"
+)
+
+// HistoryImageRef 引用已生成结果中的某一张图片，ItemIndex 从 0 开始
+type HistoryImageRef struct {
+	HistoryID string `json:"history_id"`
+	ItemIndex int    `json:"item_index"`
+}
+
+// HistoryImage 已生成图片在上游的 URI、链接和尺寸，Ref 为查询时使用的引用
+type HistoryImage struct {
+	Ref    HistoryImageRef
+	URI    string
+	URL    string
+	Width  int
+	Height int
+}
+
+// GetHistoryImage 查询一次历史记录，返回引用的图片；历史记录只能由生成它的账号查询
+func GetHistoryImage(ref HistoryImageRef, refreshToken string) (*HistoryImage, error) {
+	if ref.HistoryID == "" {
+		return nil, errors.ErrAPIRequestParamsInvalid("缺少 history_id")
+	}
+	if ref.ItemIndex < 0 {
+		return nil, errors.ErrAPIRequestParamsInvalid("item_index 不能为负数")
+	}
+	response, err := Request("POST", "/mweb/v1/get_history_by_ids", refreshToken, &RequestOptions{
+		Body: map[string]interface{}{
+			"history_ids": []string{ref.HistoryID},
+			"image_info":  standardImageInfo(),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	taskData := mapValue(response, ref.HistoryID)
+	if len(taskData) == 0 {
+		return nil, errors.ErrAPIRequestParamsInvalid(fmt.Sprintf("历史记录不存在: %s", ref.HistoryID)).SetHTTPStatusCode(404)
+	}
+	items := sliceValue(taskData["item_list"])
+	if ref.ItemIndex >= len(items) {
+		return nil, errors.ErrAPIRequestParamsInvalid(
+			fmt.Sprintf("历史记录 %s 只有 %d 张图片，item_index %d 超出范围", ref.HistoryID, len(items), ref.ItemIndex),
+		).SetHTTPStatusCode(404)
+	}
+	image := historyItemImage(items[ref.ItemIndex])
+	if image.URI == "" {
+		return nil, errors.ErrAPIImageGenerationFailed(fmt.Sprintf("历史记录 %s 第 %d 张图片缺少 image_uri", ref.HistoryID, ref.ItemIndex))
+	}
+	image.Ref = ref
+	return image, nil
+}
+
+// historyItemImage 从 item_list 的一项中取出 image.large_images[0] 的 URI、链接和尺寸
+func historyItemImage(item interface{}) *HistoryImage {
+	image := &HistoryImage{}
+	if urls := utils.ExtractImageUrls([]interface{}{item}); len(urls) > 0 {
+		image.URL = urls[0]
+	}
+	largeImages := sliceValue(mapValue(item, "image")["large_images"])
+	if len(largeImages) == 0 {
+		return image
+	}
+	large := mapValue(largeImages[0], "")
+	image.URI, _ = large["image_uri"].(string)
+	image.Width = int(numberValue(large["width"]))
+	image.Height = int(numberValue(large["height"]))
+	return image
+}
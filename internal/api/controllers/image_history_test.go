@@ -0,0 +1,37 @@
+package controllers
+
+import "testing"
+
+func TestHistoryItemImage(t *testing.T) {
+	tests := []struct {
+		name string
+		item interface{}
+		want HistoryImage
+	}{
+		{
+			name: "large image",
+			item: map[string]interface{}{
+				"image": map[string]interface{}{
+					"large_images": []interface{}{
+						map[string]interface{}{
+							"image_uri": "tos-cn-i-abc/123",
+							"image_url": "https://example.com/a.webp?x=1\\u0026y=2",
+							"width":     float64(2048),
+							"height":    float64(1152),
+						},
+					},
+				},
+			},
+			want: HistoryImage{URI: "tos-cn-i-abc/123", URL: "https://example.com/a.webp?x=1&y=2", Width: 2048, Height: 1152},
+		},
+		{name: "missing image", item: map[string]interface{}{}, want: HistoryImage{}},
+		{name: "not an object", item: "item", want: HistoryImage{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := historyItemImage(tt.item); *got != tt.want {
+				t.Errorf("historyItemImage() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
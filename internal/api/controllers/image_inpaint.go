@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gloryhry/jimeng-api-go/internal/api/builders"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/errors"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/logger"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/uploader"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/utils"
+)
+
+// InpaintSource 局部重绘的原图和遮罩，Mask 为黑白 PNG，白色为重绘区域
+type InpaintSource struct {
+	Image  []byte
+	Mask   []byte
+	Width  int
+	Height int
+}
+
+// HasAlphaChannel 只读取文件头判断原图是否为带透明通道的 PNG，用于决定未提供 mask 时是否尝试局部重绘
+// 远程 URL 不会为此下载，历史结果引用和无法读取的输入都视为不含透明通道
+func HasAlphaChannel(image interface{}) bool {
+	input, cleanup, err := imageInput(image)
+	if err != nil {
+		return false
+	}
+	defer cleanup()
+	header, err := uploader.PeekInput(context.Background(), input, utils.AlphaSniffLength)
+	return err == nil && utils.HasAlphaChannel(header)
+}
+
+// PrepareInpaint 读取原图并生成遮罩：提供 mask 时按 OpenAI 规则转换（透明区域为重绘区域），
+// 否则取原图的透明通道；两者都没有时 Mask 为 nil，由调用方按普通图生图处理
+// 需要解码整张原图，未提供 mask 时应先用 HasAlphaChannel 过滤
+func PrepareInpaint(image, mask interface{}) (*InpaintSource, error) {
+	data, err := readImageInput(image)
+	if err != nil {
+		return nil, errors.ErrAPIRequestParamsInvalid(fmt.Sprintf("读取图片失败: %v", err))
+	}
+	info, err := utils.DetectImageInfo(data)
+	if err != nil {
+		if mask == nil {
+			return &InpaintSource{Image: data}, nil
+		}
+		return nil, errors.ErrAPIRequestParamsInvalid(fmt.Sprintf("无法识别的图片: %v", err))
+	}
+	source := &InpaintSource{Image: data, Width: info.Width, Height: info.Height}
+
+	if mask == nil {
+		// 原图无法解码或透明区域无效时不影响普通编辑
+		if source.Mask, err = utils.MaskFromAlpha(data); err != nil {
+			logger.Warn(fmt.Sprintf("读取原图透明通道失败，按普通编辑处理: %v", err))
+			source.Mask = nil
+		}
+		return source, nil
+	}
+	maskData, err := readImageInput(mask)
+	if err != nil {
+		return nil, errors.ErrAPIRequestParamsInvalid(fmt.Sprintf("读取遮罩失败: %v", err))
+	}
+	if source.Mask, err = utils.BuildInpaintMask(maskData, info.Width, info.Height); err != nil {
+		return nil, errors.ErrAPIRequestParamsInvalid(err.Error())
+	}
+	return source, nil
+}
+
+// GenerateImageInpaint 局部重绘，只重新生成遮罩覆盖的区域；未指定比例时沿用原图比例
+func GenerateImageInpaint(model string, prompt string, source *InpaintSource, opts *ImageOptions, refreshToken string) (*ImageResult, error) {
+	if opts == nil {
+		opts = &ImageOptions{}
+	}
+	if opts.Ratio == "" && source != nil && source.Width > 0 && source.Height > 0 {
+		opts.Ratio = builders.NearestSize(source.Width, source.Height).Ratio
+	}
+	result, err := executeImageTask(
+		func() (string, error) {
+			return SubmitImageInpaint(model, prompt, source, opts, refreshToken)
+		},
+		func(taskID string) ([]string, error) {
+			return PollImageResult(taskID, refreshToken, 1)
+		},
+	)
+	fillImageResult(result, model, opts, refreshToken)
+	return result, err
+}
+
+// SubmitImageInpaint 上传原图和遮罩后提交局部重绘任务
+func SubmitImageInpaint(model string, prompt string, source *InpaintSource, opts *ImageOptions, refreshToken string) (string, error) {
+	if source == nil || len(source.Image) == 0 || len(source.Mask) == 0 {
+		return "", errors.ErrAPIRequestParamsInvalid("局部重绘需要原图和遮罩")
+	}
+	if opts == nil {
+		opts = &ImageOptions{}
+	}
+	ensureImageOptionDefaults(opts)
+
+	region := ParseRegionFromToken(refreshToken)
+	logger.Info(fmt.Sprintf("局部重绘 原图 %dx%d 精细度: %.2f", source.Width, source.Height, opts.SampleStrength))
+
+	uploadIDs, err := uploadImageSources([]interface{}{source.Image, source.Mask}, refreshToken, region)
+	if err != nil {
+		return "", err
+	}
+	return submitBlendDraft(blendDraft{
+		Model:       model,
+		Prompt:      prompt,
+		Options:     opts,
+		ImageCount:  1,
+		AbilityName: "inpaint",
+		AbilityList: builders.BuildInpaintAbilityList(uploadIDs[0], uploadIDs[1], opts.SampleStrength),
+	}, refreshToken, region)
+}
+
+func readImageInput(image interface{}) ([]byte, error) {
+	input, cleanup, err := imageInput(image)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	return uploader.ReadInput(context.Background(), input)
+}
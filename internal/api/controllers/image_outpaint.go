@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/gloryhry/jimeng-api-go/internal/api/builders"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/errors"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/logger"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/utils"
+)
+
+// OutpaintSource 扩图的原图和各边扩展像素，引用历史结果时直接使用上游 URI，否则提交前上传 Image
+type OutpaintSource struct {
+	Image   []byte
+	URI     string
+	Width   int
+	Height  int
+	Padding builders.OutpaintPadding
+}
+
+// PrepareOutpaint 获取原图尺寸并确定各边扩展量，提供 padding 时优先使用，否则按目标比例居中扩展
+// 已解析的历史结果（*HistoryImage）带有尺寸时直接使用其 URI，无需下载原图
+func PrepareOutpaint(image interface{}, ratio string, padding *builders.OutpaintPadding) (*OutpaintSource, error) {
+	source := &OutpaintSource{}
+	if historyImage, ok := image.(*HistoryImage); ok {
+		if historyImage.Width > 0 && historyImage.Height > 0 {
+			source.URI, source.Width, source.Height = historyImage.URI, historyImage.Width, historyImage.Height
+		} else {
+			image = historyImage.URL
+		}
+	}
+	if source.URI == "" {
+		data, err := readImageInput(image)
+		if err != nil {
+			return nil, errors.ErrAPIRequestParamsInvalid(fmt.Sprintf("读取图片失败: %v", err))
+		}
+		info, err := utils.DetectImageInfo(data)
+		if err != nil {
+			return nil, errors.ErrAPIRequestParamsInvalid(fmt.Sprintf("无法识别的图片: %v", err))
+		}
+		source.Image, source.Width, source.Height = data, info.Width, info.Height
+	}
+	var err error
+	switch {
+	case padding != nil:
+		if err := padding.Validate(source.Width, source.Height); err != nil {
+			return nil, errors.ErrAPIRequestParamsInvalid(err.Error())
+		}
+		source.Padding = *padding
+	case ratio != "":
+		if source.Padding, err = builders.PaddingForRatio(source.Width, source.Height, ratio); err != nil {
+			return nil, errors.ErrAPIRequestParamsInvalid(err.Error())
+		}
+	default:
+		return nil, errors.ErrAPIRequestParamsInvalid("需要提供目标 ratio 或 padding")
+	}
+	return source, nil
+}
+
+// GenerateImageOutpaint 扩图，输出比例取扩展后画布最接近的预设比例
+func GenerateImageOutpaint(model string, prompt string, source *OutpaintSource, opts *ImageOptions, refreshToken string) (*ImageResult, error) {
+	if opts == nil {
+		opts = &ImageOptions{}
+	}
+	if source != nil && source.Width > 0 && source.Height > 0 {
+		opts.Ratio = builders.NearestSize(source.Padding.Expand(source.Width, source.Height)).Ratio
+	}
+	result, err := executeImageTask(
+		func() (string, error) {
+			return SubmitImageOutpaint(model, prompt, source, opts, refreshToken)
+		},
+		func(taskID string) ([]string, error) {
+			return PollImageResult(taskID, refreshToken, 1)
+		},
+	)
+	fillImageResult(result, model, opts, refreshToken)
+	return result, err
+}
+
+// SubmitImageOutpaint 上传原图（引用历史结果时跳过）后提交扩图任务
+func SubmitImageOutpaint(model string, prompt string, source *OutpaintSource, opts *ImageOptions, refreshToken string) (string, error) {
+	if source == nil || (source.URI == "" && len(source.Image) == 0) {
+		return "", errors.ErrAPIRequestParamsInvalid("扩图需要原图")
+	}
+	if opts == nil {
+		opts = &ImageOptions{}
+	}
+	ensureImageOptionDefaults(opts)
+
+	region := ParseRegionFromToken(refreshToken)
+	width, height := source.Padding.Expand(source.Width, source.Height)
+	logger.Info(fmt.Sprintf("扩图 原图 %dx%d 扩展后 %dx%d 比例: %s", source.Width, source.Height, width, height, opts.Ratio))
+
+	imageURI := source.URI
+	if imageURI == "" {
+		uploadIDs, err := uploadImageSources([]interface{}{source.Image}, refreshToken, region)
+		if err != nil {
+			return "", err
+		}
+		imageURI = uploadIDs[0]
+	}
+	return submitBlendDraft(blendDraft{
+		Model:       model,
+		Prompt:      prompt,
+		Options:     opts,
+		ImageCount:  1,
+		AbilityName: "outpainting",
+		AbilityList: builders.BuildOutpaintAbilityList(imageURI, source.Width, source.Height, source.Padding, opts.SampleStrength),
+	}, refreshToken, region)
+}
@@ -0,0 +1,22 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/gloryhry/jimeng-api-go/internal/api/builders"
+)
+
+func TestPrepareOutpaintHistoryImage(t *testing.T) {
+	// 带尺寸的历史结果直接使用 URI，URL 无法访问也不影响
+	image := &HistoryImage{URI: "tos-cn-i/x", URL: "http://127.0.0.1:0/x.png", Width: 1024, Height: 1024}
+	source, err := PrepareOutpaint(image, "16:9", nil)
+	if err != nil {
+		t.Fatalf("PrepareOutpaint() error = %v", err)
+	}
+	if source.URI != "tos-cn-i/x" || source.Image != nil || source.Width != 1024 || source.Height != 1024 {
+		t.Errorf("source = %+v, want URI with history dimensions", source)
+	}
+	if want := (builders.OutpaintPadding{Left: 398, Right: 398}); source.Padding != want {
+		t.Errorf("padding = %+v, want %+v", source.Padding, want)
+	}
+}
@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/gloryhry/jimeng-api-go/internal/api/builders"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/errors"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/logger"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/utils"
+)
+
+// defaultUpscaleResolution 未指定分辨率时超分到 4k
+const defaultUpscaleResolution = "4k"
+
+// UpscaleSource 待超分的原图，引用历史结果时直接使用上游 URI，否则提交前上传 Image
+type UpscaleSource struct {
+	Image  interface{}
+	URI    string
+	Width  int
+	Height int
+}
+
+// PrepareUpscale 读取待上传的原图并获取尺寸
+func PrepareUpscale(image interface{}) (*UpscaleSource, error) {
+	data, err := readImageInput(image)
+	if err != nil {
+		return nil, errors.ErrAPIRequestParamsInvalid(fmt.Sprintf("读取图片失败: %v", err))
+	}
+	info, err := utils.DetectImageInfo(data)
+	if err != nil {
+		return nil, errors.ErrAPIRequestParamsInvalid(fmt.Sprintf("无法识别的图片: %v", err))
+	}
+	return &UpscaleSource{Image: data, Width: info.Width, Height: info.Height}, nil
+}
+
+// GenerateImageUpscale 超分放大，宽高比沿用原图，未指定分辨率时放大到 4k
+func GenerateImageUpscale(model string, source *UpscaleSource, opts *ImageOptions, refreshToken string) (*ImageResult, error) {
+	if opts == nil {
+		opts = &ImageOptions{}
+	}
+	if opts.Resolution == "" {
+		opts.Resolution = defaultUpscaleResolution
+	}
+	result, err := executeImageTask(
+		func() (string, error) {
+			return SubmitImageUpscale(model, source, opts, refreshToken)
+		},
+		func(taskID string) ([]string, error) {
+			return PollImageResult(taskID, refreshToken, 1)
+		},
+	)
+	fillImageResult(result, model, opts, refreshToken)
+	if result != nil {
+		if resolution, err := upscaleResolution(model, source, opts, ParseRegionFromToken(refreshToken)); err == nil {
+			result.Width, result.Height = resolution.Width, resolution.Height
+		}
+	}
+	return result, err
+}
+
+// SubmitImageUpscale 提交超分任务
+func SubmitImageUpscale(model string, source *UpscaleSource, opts *ImageOptions, refreshToken string) (string, error) {
+	if source == nil || (source.URI == "" && source.Image == nil) {
+		return "", errors.ErrAPIRequestParamsInvalid("超分需要原图或历史结果引用")
+	}
+	if opts == nil {
+		opts = &ImageOptions{}
+	}
+	ensureImageOptionDefaults(opts)
+
+	region := ParseRegionFromToken(refreshToken)
+	resolution, err := upscaleResolution(model, source, opts, region)
+	if err != nil {
+		return "", err
+	}
+	logger.Info(fmt.Sprintf("超分 原图 %dx%d 目标 %dx%d", source.Width, source.Height, resolution.Width, resolution.Height))
+
+	imageURI := source.URI
+	if imageURI == "" {
+		uploadIDs, err := uploadImageSources([]interface{}{source.Image}, refreshToken, region)
+		if err != nil {
+			return "", err
+		}
+		imageURI = uploadIDs[0]
+	}
+	return submitBlendDraft(blendDraft{
+		Model:       model,
+		Options:     opts,
+		ImageCount:  1,
+		AbilityName: "super_resolution",
+		AbilityList: builders.BuildSuperResolutionAbilityList(imageURI, resolution, opts.SampleStrength),
+	}, refreshToken, region)
+}
+
+// upscaleResolution 计算超分目标尺寸: 按分辨率档位的像素数等比放大原图，不套用预设比例
+// 原图尺寸未知或档位尺寸被强制时直接使用档位尺寸
+func upscaleResolution(model string, source *UpscaleSource, opts *ImageOptions, region *RegionInfo) (*builders.ResolutionResult, error) {
+	resolution, err := builders.ResolveResolution(model, region, opts.Resolution, opts.Ratio)
+	if err != nil || resolution.IsForced || source == nil || source.Width <= 0 || source.Height <= 0 {
+		return resolution, err
+	}
+	return builders.ScaleToResolution(source.Width, source.Height, resolution), nil
+}
@@ -0,0 +1,28 @@
+package controllers
+
+import "testing"
+
+func TestUpscaleResolution(t *testing.T) {
+	region := ParseRegionFromToken("token")
+	tests := []struct {
+		name       string
+		source     *UpscaleSource
+		wantWidth  int
+		wantHeight int
+	}{
+		{name: "keeps source aspect", source: &UpscaleSource{Width: 1000, Height: 500}, wantWidth: 5793, wantHeight: 2896},
+		{name: "unknown size uses preset", source: &UpscaleSource{URI: "tos-cn-i/x"}, wantWidth: 4096, wantHeight: 4096},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &ImageOptions{Resolution: "4k", Ratio: "1:1"}
+			got, err := upscaleResolution("jimeng-4.0", tt.source, opts, region)
+			if err != nil {
+				t.Fatalf("upscaleResolution() error = %v", err)
+			}
+			if got.Width != tt.wantWidth || got.Height != tt.wantHeight || got.ResolutionType != "4k" {
+				t.Errorf("upscaleResolution() = %dx%d@%s, want %dx%d@4k", got.Width, got.Height, got.ResolutionType, tt.wantWidth, tt.wantHeight)
+			}
+		})
+	}
+}
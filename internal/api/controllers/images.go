@@ -1,16 +1,20 @@
 package controllers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"math/rand"
+	"mime/multipart"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/gloryhry/jimeng-api-go/internal/api/builders"
 	"github.com/gloryhry/jimeng-api-go/internal/api/consts"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/config"
 	"github.com/gloryhry/jimeng-api-go/internal/pkg/errors"
 	"github.com/gloryhry/jimeng-api-go/internal/pkg/logger"
 	"github.com/gloryhry/jimeng-api-go/internal/pkg/poller"
@@ -36,6 +40,31 @@ type ImageOptions struct {
 	SampleStrength   float64
 	NegativePrompt   string
 	IntelligentRatio bool
+	Seed             int64                     // 0 表示随机，提交时写回实际使用的种子
+	MultiImage       *bool                     // 多图模式，nil 表示未指定
+	Count            int                       // 多图模式下生成的张数，0 取配置默认值
+	References       []builders.BlendReference // 图生图各参考图的角色和强度，与输入图片一一对应，可为空
+
+	multiImageFromKeyword bool // 多图模式由提示词关键词触发而非请求显式指定
+}
+
+// ImageResult 图片生成结果
+type ImageResult struct {
+	HistoryID  string
+	URLs       []string
+	Width      int      // 实际输出宽度
+	Height     int      // 实际输出高度
+	HistoryIDs []string // 按 n 多次提交时的全部历史ID
+	Requested  int      // 请求的图片数量，0 表示未指定 n
+	Failures   []string // 失败的提交及原因
+	Seed       int64    // 提交使用的种子
+	Seeds      []int64  // 按 n 多次提交时与 URLs 一一对应的种子
+	MultiImage int      // 多图模式下每次提交请求的张数，0 表示普通生成
+}
+
+// Partial 是否只收集到部分请求的图片
+func (r *ImageResult) Partial() bool {
+	return r.Requested > 0 && len(r.URLs) < r.Requested
 }
 
 // GetResolutionParams 返回分辨率配置信息
@@ -65,24 +94,56 @@ func GetImageModel(model string, isInternational bool) (string, error) {
 	return "", errors.ErrAPIRequestParamsInvalid(fmt.Sprintf("不支持的模型 \"%s\"", model))
 }
 
-// GenerateImages 文生图
-func GenerateImages(model string, prompt string, opts *ImageOptions, refreshToken string) ([]string, error) {
-	tm := task.NewTaskManager()
-	result, err := tm.ExecuteTask(
+// GenerateImages 文生图，提交成功但轮询失败时返回的结果仍带有 HistoryID
+func GenerateImages(model string, prompt string, opts *ImageOptions, refreshToken string) (*ImageResult, error) {
+	if opts == nil {
+		opts = &ImageOptions{}
+	}
+	count, err := applyMultiImage(model, prompt, opts)
+	if err != nil {
+		return nil, err
+	}
+	expectedCount := 4
+	if count > 0 {
+		expectedCount = count
+	}
+	result, err := executeImageTask(
 		func() (string, error) {
 			return SubmitImageGeneration(model, prompt, opts, refreshToken)
 		},
+		func(taskID string) ([]string, error) {
+			return PollImageResult(taskID, refreshToken, expectedCount)
+		},
+	)
+	fillImageResult(result, model, opts, refreshToken)
+	return result, err
+}
+
+func executeImageTask(submit func() (string, error), poll func(string) ([]string, error)) (*ImageResult, error) {
+	result := &ImageResult{}
+	tm := task.NewTaskManager()
+	_, err := tm.ExecuteTask(
+		func() (string, error) {
+			historyID, err := submit()
+			result.HistoryID = historyID
+			return historyID, err
+		},
 		func(taskID string) (interface{}, error) {
-			return PollImageResult(taskID, refreshToken, 4)
+			urls, err := poll(taskID)
+			result.URLs = urls
+			return urls, err
 		},
 	)
 	if err != nil {
+		if result.HistoryID != "" {
+			return result, err
+		}
 		return nil, err
 	}
-	return result.([]string), nil
+	return result, nil
 }
 
-// SubmitImageGeneration 提交文生图任务
+// SubmitImageGeneration 提交文生图任务，多图模式需先由 applyMultiImage 解析并写回 opts
 func SubmitImageGeneration(model string, prompt string, opts *ImageOptions, refreshToken string) (string, error) {
 	if opts == nil {
 		opts = &ImageOptions{}
@@ -108,20 +169,20 @@ func SubmitImageGeneration(model string, prompt string, opts *ImageOptions, refr
 }
 
 // GenerateImageComposition 图生图
-func GenerateImageComposition(model string, prompt string, images []interface{}, opts *ImageOptions, refreshToken string) ([]string, error) {
-	tm := task.NewTaskManager()
-	result, err := tm.ExecuteTask(
+func GenerateImageComposition(model string, prompt string, images []interface{}, opts *ImageOptions, refreshToken string) (*ImageResult, error) {
+	if opts == nil {
+		opts = &ImageOptions{}
+	}
+	result, err := executeImageTask(
 		func() (string, error) {
 			return SubmitImageComposition(model, prompt, images, opts, refreshToken)
 		},
-		func(taskID string) (interface{}, error) {
+		func(taskID string) ([]string, error) {
 			return PollImageResult(taskID, refreshToken, 1)
 		},
 	)
-	if err != nil {
-		return nil, err
-	}
-	return result.([]string), nil
+	fillImageResult(result, model, opts, refreshToken)
+	return result, err
 }
 
 // SubmitImageComposition 提交图生图任务
@@ -135,43 +196,72 @@ func SubmitImageComposition(model string, prompt string, images []interface{}, o
 	if opts == nil {
 		opts = &ImageOptions{}
 	}
+	if len(opts.References) > 0 && len(opts.References) != len(images) {
+		return "", errors.ErrAPIRequestParamsInvalid(fmt.Sprintf("参考图设置数量 %d 与图片数量 %d 不一致", len(opts.References), len(images)))
+	}
+	for i, reference := range opts.References {
+		if err := reference.Validate(); err != nil {
+			return "", errors.ErrAPIRequestParamsInvalid(fmt.Sprintf("images[%d] %v", i, err))
+		}
+	}
 	ensureImageOptionDefaults(opts)
 
 	region := ParseRegionFromToken(refreshToken)
-	mappedModel, err := GetImageModel(model, region.IsInternational)
+	logger.Info(fmt.Sprintf("图生图功能 %d张图片 精细度: %.2f", len(images), opts.SampleStrength))
+
+	uploadIDs, err := uploadImageSources(images, refreshToken, region)
 	if err != nil {
 		return "", err
 	}
+	return submitBlendDraft(blendDraft{
+		Model:       model,
+		Prompt:      prompt,
+		Options:     opts,
+		ImageCount:  len(uploadIDs),
+		AbilityName: "byte_edit",
+		AbilityList: builders.BuildBlendAbilityList(uploadIDs, opts.SampleStrength, opts.References),
+		Roles:       builders.BlendRoles(opts.References),
+	}, refreshToken, region)
+}
 
-	// 使用 payload-builder 处理分辨率
-	resolutionResult, err := builders.ResolveResolution(model, region, opts.Resolution, opts.Ratio)
+// blendDraft 以 blend 草稿提交的图片任务，图生图、局部重绘等只在能力列表上有区别
+type blendDraft struct {
+	Model       string
+	Prompt      string
+	Options     *ImageOptions
+	ImageCount  int    // 输入图片数量，决定提示词前缀和 min_version
+	AbilityName string // metrics_extra 中的能力名称
+	AbilityList []map[string]interface{}
+	Roles       []string // 与能力一一对应的参考图角色，可为空
+}
+
+func submitBlendDraft(draft blendDraft, refreshToken string, region *RegionInfo) (string, error) {
+	opts := draft.Options
+	mappedModel, err := GetImageModel(draft.Model, region.IsInternational)
 	if err != nil {
 		return "", err
 	}
-	logResolutionInfo(model, resolutionResult, region)
-
-	logger.Info(fmt.Sprintf("使用模型: %s 映射模型: %s 图生图功能 %d张图片 %dx%d 精细度: %.2f",
-		model, mappedModel, len(images), resolutionResult.Width, resolutionResult.Height, opts.SampleStrength))
 
-	uploaderExec := adaptRequestForUploader()
-	uploadIDs := make([]string, 0, len(images))
-	for idx, item := range images {
-		id, err := uploadImageSource(uploaderExec, item, refreshToken, region)
-		if err != nil {
-			return "", errors.ErrAPIRequestFailed(fmt.Sprintf("图片 %d 上传失败: %v", idx+1, err))
-		}
-		uploadIDs = append(uploadIDs, id)
+	// 使用 payload-builder 处理分辨率
+	resolutionResult, err := builders.ResolveResolution(draft.Model, region, opts.Resolution, opts.Ratio)
+	if err != nil {
+		return "", err
 	}
+	logResolutionInfo(draft.Model, resolutionResult, region)
+
+	logger.Info(fmt.Sprintf("使用模型: %s 映射模型: %s 能力: %s %dx%d 精细度: %.2f",
+		draft.Model, mappedModel, draft.AbilityName, resolutionResult.Width, resolutionResult.Height, opts.SampleStrength))
 
 	componentID := utils.UUID(true)
 	submitID := utils.UUID(true)
 
 	// 使用 payload-builder 构建 core_param
 	coreParam := builders.BuildCoreParam(builders.BuildCoreParamOptions{
-		UserModel:        model,
+		UserModel:        draft.Model,
 		Model:            mappedModel,
-		Prompt:           prompt,
-		ImageCount:       len(images),
+		Prompt:           draft.Prompt,
+		ImageCount:       draft.ImageCount,
+		Seed:             opts.Seed,
 		SampleStrength:   opts.SampleStrength,
 		Resolution:       resolutionResult,
 		IntelligentRatio: opts.IntelligentRatio,
@@ -179,11 +269,15 @@ func SubmitImageComposition(model string, prompt string, images []interface{}, o
 	})
 
 	// 构建 metrics_extra 中的 abilityList
-	metricsAbilityList := make([]builders.Ability, len(uploadIDs))
-	for i := range uploadIDs {
+	metricsAbilityList := make([]builders.Ability, len(draft.AbilityList))
+	for i, ability := range draft.AbilityList {
+		strength, ok := ability["strength"].(float64)
+		if !ok {
+			strength = opts.SampleStrength
+		}
 		metricsAbilityList[i] = builders.Ability{
-			AbilityName: "byte_edit",
-			Strength:    opts.SampleStrength,
+			AbilityName: draft.AbilityName,
+			Strength:    strength,
 			Source: &struct {
 				ImageURL string `json:"imageUrl"`
 			}{
@@ -194,7 +288,7 @@ func SubmitImageComposition(model string, prompt string, images []interface{}, o
 
 	// 使用 payload-builder 构建 metrics_extra
 	metricsExtra := builders.BuildMetricsExtra(builders.BuildMetricsExtraOptions{
-		UserModel:      model,
+		UserModel:      draft.Model,
 		Model:          mappedModel,
 		RegionInfo:     region,
 		SubmitID:       submitID,
@@ -204,8 +298,7 @@ func SubmitImageComposition(model string, prompt string, images []interface{}, o
 	})
 
 	// 使用 payload-builder 构建 draft_content
-	abilityList := builders.BuildBlendAbilityList(uploadIDs, opts.SampleStrength)
-	promptPlaceholderInfoList := builders.BuildPromptPlaceholderList(len(uploadIDs))
+	promptPlaceholderInfoList := builders.BuildPromptPlaceholderList(len(draft.AbilityList), draft.Roles)
 	posteditParam := map[string]interface{}{
 		"type":          "",
 		"id":            utils.UUID(true),
@@ -216,10 +309,10 @@ func SubmitImageComposition(model string, prompt string, images []interface{}, o
 		ComponentID:               componentID,
 		GenerateType:              "blend",
 		CoreParam:                 coreParam,
-		AbilityList:               abilityList,
+		AbilityList:               draft.AbilityList,
 		PromptPlaceholderInfoList: promptPlaceholderInfoList,
 		PosteditParam:             posteditParam,
-		ImageCount:                len(images),
+		ImageCount:                draft.ImageCount,
 	})
 
 	// 使用 payload-builder 构建完整请求
@@ -254,7 +347,7 @@ func SubmitImageComposition(model string, prompt string, images []interface{}, o
 }
 
 // GenerateImageEdits 兼容 OpenAI 接口
-func GenerateImageEdits(model string, prompt string, images []interface{}, opts *ImageOptions, refreshToken string) ([]string, error) {
+func GenerateImageEdits(model string, prompt string, images []interface{}, opts *ImageOptions, refreshToken string) (*ImageResult, error) {
 	if opts == nil {
 		opts = &ImageOptions{}
 	}
@@ -262,6 +355,17 @@ func GenerateImageEdits(model string, prompt string, images []interface{}, opts
 	return GenerateImageComposition(model, prompt, images, opts, refreshToken)
 }
 
+// GenerateImageVariations 基于图生图流程生成单张图片的变体，提示词和未指定的强度取 variation 配置
+func GenerateImageVariations(model string, image interface{}, opts *ImageOptions, refreshToken string) (*ImageResult, error) {
+	if opts == nil {
+		opts = &ImageOptions{}
+	}
+	if opts.SampleStrength <= 0 {
+		opts.SampleStrength = config.System.Variation.Strength
+	}
+	return GenerateImageComposition(model, config.System.Variation.Prompt, []interface{}{image}, opts, refreshToken)
+}
+
 // SubmitImageEdits 提交编辑任务
 func SubmitImageEdits(model string, prompt string, images []interface{}, opts *ImageOptions, refreshToken string) (string, error) {
 	if opts == nil {
@@ -274,8 +378,8 @@ func SubmitImageEdits(model string, prompt string, images []interface{}, opts *I
 func submitImagesInternal(mappedModel, requestedModel, prompt string, opts *ImageOptions, refreshToken string, region *RegionInfo, resolutionResult *builders.ResolutionResult) (string, error) {
 	logger.Info(fmt.Sprintf("生成参数: 分辨率=%s 比例=%s", opts.Resolution, opts.Ratio))
 
-	if shouldUseMultiImage(requestedModel, prompt) {
-		return submitJimeng40MultiImages(mappedModel, requestedModel, prompt, opts, refreshToken, region, resolutionResult)
+	if opts.MultiImage != nil && *opts.MultiImage {
+		return submitMultiImages(mappedModel, requestedModel, prompt, opts.Count, opts, refreshToken, region, resolutionResult)
 	}
 
 	componentID := utils.UUID(true)
@@ -287,7 +391,7 @@ func submitImagesInternal(mappedModel, requestedModel, prompt string, opts *Imag
 		Model:            mappedModel,
 		Prompt:           prompt,
 		NegativePrompt:   opts.NegativePrompt,
-		Seed:             randomSeed(),
+		Seed:             opts.Seed,
 		SampleStrength:   opts.SampleStrength,
 		Resolution:       resolutionResult,
 		IntelligentRatio: opts.IntelligentRatio,
@@ -368,10 +472,11 @@ func PollImageResult(historyID string, refreshToken string, expectedCount int) (
 	return urls, nil
 }
 
-func submitJimeng40MultiImages(mappedModel, requestedModel, prompt string, opts *ImageOptions, refreshToken string, region *RegionInfo, resolutionResult *builders.ResolutionResult) (string, error) {
-	targetCount := extractTargetCount(prompt)
+// submitMultiImages 以 ImageMultiGenerate 场景提交多图生成，上游按提示词中的张数出图
+func submitMultiImages(mappedModel, requestedModel, prompt string, count int, opts *ImageOptions, refreshToken string, region *RegionInfo, resolutionResult *builders.ResolutionResult) (string, error) {
+	prompt = multiImagePrompt(prompt, count, !opts.multiImageFromKeyword)
 
-	logger.Info(fmt.Sprintf("使用 多图生成: %d张图片 %dx%d 精细度: %.2f", targetCount, resolutionResult.Width, resolutionResult.Height, opts.SampleStrength))
+	logger.Info(fmt.Sprintf("使用 多图生成: %d张图片 %dx%d 精细度: %.2f", count, resolutionResult.Width, resolutionResult.Height, opts.SampleStrength))
 
 	componentID := utils.UUID(true)
 	submitID := utils.UUID(true)
@@ -382,7 +487,7 @@ func submitJimeng40MultiImages(mappedModel, requestedModel, prompt string, opts
 		Model:            mappedModel,
 		Prompt:           prompt,
 		NegativePrompt:   opts.NegativePrompt,
-		Seed:             randomSeed(),
+		Seed:             opts.Seed,
 		SampleStrength:   opts.SampleStrength,
 		Resolution:       resolutionResult,
 		IntelligentRatio: opts.IntelligentRatio,
@@ -440,6 +545,21 @@ func submitJimeng40MultiImages(mappedModel, requestedModel, prompt string, opts
 	return historyID, nil
 }
 
+// fillImageResult 记录实际使用的输出宽高和种子，宽高与提交时的分辨率处理一致
+func fillImageResult(result *ImageResult, model string, opts *ImageOptions, refreshToken string) {
+	if result == nil {
+		return
+	}
+	result.Seed = opts.Seed
+	if opts.MultiImage != nil && *opts.MultiImage {
+		result.MultiImage = opts.Count
+	}
+	resolution, err := builders.ResolveResolution(model, ParseRegionFromToken(refreshToken), opts.Resolution, opts.Ratio)
+	if err == nil {
+		result.Width, result.Height = resolution.Width, resolution.Height
+	}
+}
+
 func ensureImageOptionDefaults(opts *ImageOptions) {
 	if opts.Ratio == "" {
 		opts.Ratio = "1:1"
@@ -450,6 +570,9 @@ func ensureImageOptionDefaults(opts *ImageOptions) {
 	if opts.SampleStrength <= 0 {
 		opts.SampleStrength = 0.5
 	}
+	if opts.Seed == 0 {
+		opts.Seed = randomSeed()
+	}
 }
 
 func logResolutionInfo(model string, resolution *builders.ResolutionResult, region *RegionInfo) {
@@ -476,10 +599,79 @@ func ensureCredit(refreshToken string) (*CreditInfo, error) {
 	return GetCredit(refreshToken)
 }
 
-func shouldUseMultiImage(model, prompt string) bool {
-	if model != "jimeng-4.0" {
-		return false
+// resolveMultiImage 返回多图模式的张数，0 表示普通生成
+// 显式指定 multi_image 或 count 时以请求为准；都未指定时只有开启 keywordFallback 才按提示词关键词判断
+func resolveMultiImage(model, prompt string, opts *ImageOptions) (int, error) {
+	cfg := config.System.MultiImage
+	if opts.MultiImage != nil && !*opts.MultiImage {
+		return 0, nil
+	}
+	if opts.MultiImage == nil && opts.Count <= 0 {
+		if !cfg.KeywordFallback || !supportsMultiImage(model) || !hasMultiImageKeyword(prompt) {
+			return 0, nil
+		}
+		count := extractTargetCount(prompt, cfg.DefaultCount)
+		if cfg.MaxCount > 0 && count > cfg.MaxCount {
+			count = cfg.MaxCount
+		}
+		return count, nil
+	}
+	if !supportsMultiImage(model) {
+		return 0, errors.ErrAPIRequestParamsInvalid(
+			fmt.Sprintf("模型 \"%s\" 不支持多图生成，支持的模型: %s", model, strings.Join(cfg.Models, ", ")),
+		)
+	}
+	count := opts.Count
+	if count <= 0 {
+		count = cfg.DefaultCount
+	}
+	if cfg.MaxCount <= 0 && count < 1 {
+		return 0, errors.ErrAPIRequestParamsInvalid("count 必须大于等于 1")
+	}
+	if cfg.MaxCount > 0 && (count < 1 || count > cfg.MaxCount) {
+		return 0, errors.ErrAPIRequestParamsInvalid(fmt.Sprintf("count 必须在 1 到 %d 之间", cfg.MaxCount))
+	}
+	return count, nil
+}
+
+// applyMultiImage 解析多图模式的张数并写回 opts，后续提交直接使用写回的结果，不再重复解析
+func applyMultiImage(model, prompt string, opts *ImageOptions) (int, error) {
+	count, err := resolveMultiImage(model, prompt, opts)
+	if err != nil || count <= 0 {
+		return 0, err
 	}
+	multiImage := true
+	opts.multiImageFromKeyword = opts.MultiImage == nil && opts.Count <= 0
+	opts.MultiImage, opts.Count = &multiImage, count
+	return count, nil
+}
+
+// multiImagePrompt 确保提示词中的张数与实际提交的张数一致
+// 请求显式指定了张数（multi_image 或 count）时，以请求为准替换提示词中已写明的张数；否则只在提示词未写明时补充
+func multiImagePrompt(prompt string, count int, explicit bool) string {
+	loc := multiImageCountRegex.FindStringSubmatchIndex(prompt)
+	if loc == nil {
+		return fmt.Sprintf("%s，生成%d张图片", prompt, count)
+	}
+	if !explicit {
+		return prompt
+	}
+	return prompt[:loc[2]] + strconv.Itoa(count) + prompt[loc[3]:]
+}
+
+func supportsMultiImage(model string) bool {
+	if model == "" {
+		model = defaultImageModel
+	}
+	for _, supported := range config.System.MultiImage.Models {
+		if supported == model {
+			return true
+		}
+	}
+	return false
+}
+
+func hasMultiImageKeyword(prompt string) bool {
 	for _, keyword := range multiImageKeywords {
 		if strings.Contains(prompt, keyword) {
 			return true
@@ -488,14 +680,17 @@ func shouldUseMultiImage(model, prompt string) bool {
 	return multiImageCountRegex.MatchString(prompt)
 }
 
-func extractTargetCount(prompt string) int {
+func extractTargetCount(prompt string, defaultCount int) int {
 	matches := multiImageCountRegex.FindStringSubmatch(prompt)
 	if len(matches) >= 2 {
 		if val, err := strconv.Atoi(matches[1]); err == nil && val > 0 {
 			return val
 		}
 	}
-	return 4
+	if defaultCount <= 0 {
+		return 4
+	}
+	return defaultCount
 }
 
 func pollHistory(historyID, refreshToken string, pollOptions *poller.PollingOptions, imageInfo map[string]interface{}) (map[string]interface{}, *poller.PollingResult, error) {
@@ -558,23 +753,117 @@ func randomSeed() int64 {
 	return rand.Int63n(4294967296)
 }
 
-func uploadImageSource(exec uploader.RequestFunc, image interface{}, refreshToken string, region *RegionInfo) (string, error) {
+// UploadedImage 已上传到 ImageX 的输入图片，提交时直接使用其 URI
+type UploadedImage struct {
+	URI string
+}
+
+// UploadImages 上传输入图片，返回与输入顺序一致的 UploadedImage；同一 token 的多次提交复用结果，避免重复上传
+func UploadImages(images []interface{}, refreshToken string) ([]interface{}, error) {
+	uris, err := uploadImageSources(images, refreshToken, ParseRegionFromToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	uploaded := make([]interface{}, len(uris))
+	for i, uri := range uris {
+		uploaded[i] = UploadedImage{URI: uri}
+	}
+	return uploaded, nil
+}
+
+// uploadImageSources 并发上传多张图片，返回与输入顺序一致的 URI
+// 任意一张失败时取消其余上传，错误信息中包含失败图片的序号
+func uploadImageSources(images []interface{}, refreshToken string, region *RegionInfo) ([]string, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	limit := config.System.UploadConcurrency
+	if limit <= 0 {
+		limit = 1
+	}
+	up := uploader.New(adaptRequestForUploader())
+	uris := make([]string, len(images))
+	semaphore := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	var firstErr error
+	var errOnce sync.Once
+
+	for idx, item := range images {
+		wg.Add(1)
+		go func(idx int, item interface{}) {
+			defer wg.Done()
+			select {
+			case semaphore <- struct{}{}:
+				defer func() { <-semaphore }()
+			case <-ctx.Done():
+				return
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			uri, err := uploadImageSource(ctx, up, item, refreshToken, region)
+			if err != nil {
+				errOnce.Do(func() {
+					firstErr = errors.ErrAPIRequestFailed(fmt.Sprintf("图片 %d 上传失败: %v", idx+1, err))
+					cancel()
+				})
+				return
+			}
+			uris[idx] = uri
+		}(idx, item)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return uris, nil
+}
+
+// uploadImageSource 将 []byte、string（URL、data URI、BASE64、本地素材 ID）或上传文件转为统一输入后上传，
+// 已上传的 UploadedImage、已解析的 *HistoryImage 或 HistoryImageRef 引用的已生成结果直接返回其上游 URI
+func uploadImageSource(ctx context.Context, up uploader.Uploader, image interface{}, refreshToken string, region *RegionInfo) (string, error) {
 	switch value := image.(type) {
-	case []byte:
-		result, err := uploader.UploadImageBuffer(exec, value, refreshToken, region)
+	case UploadedImage:
+		return value.URI, nil
+	case *HistoryImage:
+		return value.URI, nil
+	}
+	if ref, ok := image.(HistoryImageRef); ok {
+		// 已生成的结果直接使用上游 URI，无需下载后重新上传
+		historyImage, err := GetHistoryImage(ref, refreshToken)
 		if err != nil {
 			return "", err
 		}
-		return result.URI, nil
+		return historyImage.URI, nil
+	}
+	input, cleanup, err := imageInput(image)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+	result, err := up.Upload(ctx, input, refreshToken, region)
+	if err != nil {
+		return "", err
+	}
+	return result.URI, nil
+}
+
+// imageInput 将 []byte、string 或上传文件转为上传器输入，上传文件直接从请求体（或 multipart 落盘的临时文件）流式读取
+func imageInput(image interface{}) (uploader.Input, func(), error) {
+	noop := func() {}
+	switch value := image.(type) {
+	case []byte:
+		return uploader.BytesInput(value), noop, nil
 	case string:
-		result, err := uploader.UploadImageFromURL(exec, value, refreshToken, region)
+		return uploader.StringInput(value), noop, nil
+	case *multipart.FileHeader:
+		file, err := value.Open()
 		if err != nil {
-			return "", err
+			return uploader.Input{}, noop, err
 		}
-		return result.URI, nil
-	default:
-		return "", fmt.Errorf("不支持的图片输入类型: %T", image)
+		return uploader.ReaderInput(file, value.Size), func() { file.Close() }, nil
 	}
+	return uploader.Input{}, noop, fmt.Errorf("不支持的图片输入类型: %T", image)
 }
 
 func adaptRequestForUploader() uploader.RequestFunc {
@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/config"
+)
+
+func TestResolveMultiImage(t *testing.T) {
+	previous := config.System
+	t.Cleanup(func() { config.System = previous })
+	config.System = &config.SystemConfig{
+		MultiImage: config.MultiImageConfig{
+			Models:       []string{"jimeng-4.0", "jimeng-4.5"},
+			DefaultCount: 4,
+			MaxCount:     6,
+		},
+	}
+	enabled, disabled := true, false
+	tests := []struct {
+		name     string
+		model    string
+		prompt   string
+		opts     ImageOptions
+		fallback bool
+		want     int
+		wantErr  bool
+	}{
+		{name: "explicit default count", model: "jimeng-4.5", opts: ImageOptions{MultiImage: &enabled}, want: 4},
+		{name: "explicit count", model: "jimeng-4.0", opts: ImageOptions{MultiImage: &enabled, Count: 3}, want: 3},
+		{name: "count implies multi image", model: "jimeng-4.0", opts: ImageOptions{Count: 2}, want: 2},
+		{name: "explicit false wins over keywords", model: "jimeng-4.0", prompt: "连续的绘本插画", opts: ImageOptions{MultiImage: &disabled}, fallback: true},
+		{name: "unsupported model", model: "jimeng-3.0", opts: ImageOptions{MultiImage: &enabled}, wantErr: true},
+		{name: "count over max", model: "jimeng-4.0", opts: ImageOptions{Count: 7}, wantErr: true},
+		{name: "keywords ignored without fallback", model: "jimeng-4.0", prompt: "生成5张海报"},
+		{name: "keyword fallback", model: "jimeng-4.5", prompt: "生成5张海报", fallback: true, want: 5},
+		{name: "keyword fallback default count", model: "jimeng-4.0", prompt: "连续的绘本插画", fallback: true, want: 4},
+		{name: "keyword fallback capped", model: "jimeng-4.0", prompt: "生成9张海报", fallback: true, want: 6},
+		{name: "keyword fallback unsupported model", model: "jimeng-3.0", prompt: "生成5张海报", fallback: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.System.MultiImage.KeywordFallback = tt.fallback
+			got, err := resolveMultiImage(tt.model, tt.prompt, &tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveMultiImage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("resolveMultiImage() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyMultiImage(t *testing.T) {
+	previous := config.System
+	t.Cleanup(func() { config.System = previous })
+	config.System = &config.SystemConfig{
+		MultiImage: config.MultiImageConfig{
+			Models:          []string{"jimeng-4.0"},
+			DefaultCount:    4,
+			MaxCount:        6,
+			KeywordFallback: true,
+		},
+	}
+	enabled := true
+	tests := []struct {
+		name        string
+		prompt      string
+		opts        ImageOptions
+		want        int
+		wantKeyword bool
+	}{
+		{name: "plain prompt", prompt: "海报"},
+		{name: "explicit", prompt: "生成5张海报", opts: ImageOptions{MultiImage: &enabled, Count: 3}, want: 3},
+		{name: "keyword fallback", prompt: "生成5张海报", want: 5, wantKeyword: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applyMultiImage("jimeng-4.0", tt.prompt, &tt.opts)
+			if err != nil {
+				t.Fatalf("applyMultiImage() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("applyMultiImage() = %d, want %d", got, tt.want)
+			}
+			if tt.want > 0 && (tt.opts.MultiImage == nil || !*tt.opts.MultiImage || tt.opts.Count != tt.want) {
+				t.Errorf("opts not written back: MultiImage=%v Count=%d", tt.opts.MultiImage, tt.opts.Count)
+			}
+			if tt.opts.multiImageFromKeyword != tt.wantKeyword {
+				t.Errorf("multiImageFromKeyword = %v, want %v", tt.opts.multiImageFromKeyword, tt.wantKeyword)
+			}
+		})
+	}
+}
+
+func TestMultiImagePrompt(t *testing.T) {
+	tests := []struct {
+		name     string
+		prompt   string
+		count    int
+		explicit bool
+		want     string
+	}{
+		{name: "appends count", prompt: "海报", count: 3, want: "海报，生成3张图片"},
+		{name: "explicit count appended", prompt: "海报", count: 3, explicit: true, want: "海报，生成3张图片"},
+		{name: "explicit count replaces prompt count", prompt: "生成5张海报", count: 3, explicit: true, want: "生成3张海报"},
+		{name: "keyword count kept", prompt: "生成5张海报", count: 5, want: "生成5张海报"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := multiImagePrompt(tt.prompt, tt.count, tt.explicit); got != tt.want {
+				t.Errorf("multiImagePrompt() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
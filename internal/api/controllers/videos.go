@@ -1,8 +1,10 @@
 package controllers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"mime/multipart"
 	"regexp"
 	"strings"
 	"time"
@@ -18,30 +20,51 @@ import (
 
 const defaultVideoModel = consts.DefaultVideoModel
 
+// videoURLPattern 视频URL正则匹配模式
+var videoURLPattern = regexp.MustCompile(`https://v[0-9]+-artist\.vlabvod\.com/[^"\s]+`)
+
 // VideoOptions 视频生成选项
 type VideoOptions struct {
 	Ratio       string
 	Resolution  string
 	Duration    int
-	FilePaths   []string
+	FilePaths   []interface{} // URL、data URI、BASE64、本地素材 ID 字符串、HistoryImageRef 或已解析的 *HistoryImage
 	FileBuffers [][]byte
+	Files       []*multipart.FileHeader // 上传的文件，按需流式读取
+	Seed        int64                   // 0 表示随机，提交时写回实际使用的种子
+}
+
+// VideoResult 视频生成结果
+type VideoResult struct {
+	HistoryID string
+	URL       string
+	Seed      int64
 }
 
-// GenerateVideo 文生视频
-func GenerateVideo(model string, prompt string, opts *VideoOptions, refreshToken string) (string, error) {
+// GenerateVideo 文生视频，提交成功但轮询失败时返回的结果仍带有 HistoryID
+func GenerateVideo(model string, prompt string, opts *VideoOptions, refreshToken string) (*VideoResult, error) {
+	result := &VideoResult{}
 	tm := task.NewTaskManager()
-	result, err := tm.ExecuteTask(
+	_, err := tm.ExecuteTask(
 		func() (string, error) {
-			return SubmitVideoGeneration(model, prompt, opts, refreshToken)
+			historyID, err := SubmitVideoGeneration(model, prompt, opts, refreshToken)
+			result.HistoryID = historyID
+			result.Seed = opts.Seed
+			return historyID, err
 		},
 		func(taskID string) (interface{}, error) {
-			return PollVideoResult(taskID, refreshToken)
+			videoURL, err := PollVideoResult(taskID, refreshToken)
+			result.URL = videoURL
+			return videoURL, err
 		},
 	)
 	if err != nil {
-		return "", err
+		if result.HistoryID != "" {
+			return result, err
+		}
+		return nil, err
 	}
-	return result.(string), nil
+	return result, nil
 }
 
 // SubmitVideoGeneration 提交视频生成任务
@@ -55,6 +78,9 @@ func SubmitVideoGeneration(model string, prompt string, opts *VideoOptions, refr
 	if strings.TrimSpace(opts.Resolution) == "" {
 		opts.Resolution = "720p"
 	}
+	if opts.Seed == 0 {
+		opts.Seed = randomSeed()
+	}
 	region := ParseRegionFromToken(refreshToken)
 	mappedModel := getVideoModel(model, region)
 
@@ -137,26 +163,29 @@ func SubmitVideoGeneration(model string, prompt string, opts *VideoOptions, refr
 	}
 
 	uploadIDs := make([]string, 0)
-	exec := adaptRequestForUploader()
+	up := uploader.NewForScene(adaptRequestForUploader(), uploader.SceneVideoCover)
+	sources := make([]interface{}, 0, len(opts.FileBuffers)+len(opts.Files)+len(opts.FilePaths))
 	for _, buf := range opts.FileBuffers {
-		if buf == nil {
-			continue
+		if buf != nil {
+			sources = append(sources, buf)
 		}
-		result, err := uploader.UploadImageBuffer(exec, buf, refreshToken, region)
-		if err != nil {
-			return "", errors.ErrFileUploadFailed(fmt.Sprintf("上传本地图片失败: %v", err))
+	}
+	for _, fh := range opts.Files {
+		if fh != nil {
+			sources = append(sources, fh)
 		}
-		uploadIDs = append(uploadIDs, result.URI)
 	}
 	for _, path := range opts.FilePaths {
-		if path == "" {
-			continue
+		if path != nil && path != "" {
+			sources = append(sources, path)
 		}
-		result, err := uploader.UploadImageFromURL(exec, path, refreshToken, region)
+	}
+	for _, source := range sources {
+		uri, err := uploadImageSource(context.Background(), up, source, refreshToken, region)
 		if err != nil {
-			return "", errors.ErrFileUploadFailed(fmt.Sprintf("上传URL图片失败: %v", err))
+			return "", errors.ErrFileUploadFailed(fmt.Sprintf("上传图片失败: %v", err))
 		}
-		uploadIDs = append(uploadIDs, result.URI)
+		uploadIDs = append(uploadIDs, uri)
 	}
 
 	var firstFrame, endFrame map[string]interface{}
@@ -252,7 +281,7 @@ func SubmitVideoGeneration(model string, prompt string, opts *VideoOptions, refr
 							"id":                 utils.UUID(true),
 							"video_gen_inputs":   genInputs,
 							"video_aspect_ratio": opts.Ratio,
-							"seed":               randomSeed(),
+							"seed":               opts.Seed,
 							"model_req_key":      mappedModel,
 							"priority":           0,
 						},
@@ -347,6 +376,30 @@ func PollVideoResult(historyID string, refreshToken string) (string, error) {
 	return videoURL, nil
 }
 
+// GetVideoURL 查询一次历史记录获取已生成视频的链接，不等待生成
+func GetVideoURL(historyID string, refreshToken string) (string, error) {
+	response, err := Request("POST", "/mweb/v1/get_history_by_ids", refreshToken, &RequestOptions{
+		Body: map[string]interface{}{"history_ids": []string{historyID}},
+	})
+	if err != nil {
+		return "", err
+	}
+	responseBytes, _ := json.Marshal(response)
+	if match := videoURLPattern.FindString(string(responseBytes)); match != "" {
+		return match, nil
+	}
+	taskData := mapValue(response, historyID)
+	if len(taskData) == 0 {
+		return "", errors.ErrAPIRequestParamsInvalid(fmt.Sprintf("视频记录不存在: %s", historyID)).SetHTTPStatusCode(404)
+	}
+	for _, item := range sliceValue(taskData["item_list"]) {
+		if videoURL := utils.ExtractVideoUrl(item); videoURL != "" {
+			return videoURL, nil
+		}
+	}
+	return "", errors.ErrAPIVideoGenerationFailed(fmt.Sprintf("视频尚未生成完成: %s", historyID)).SetHTTPStatusCode(409)
+}
+
 // getVideoModel 根据区域获取视频模型映射
 func getVideoModel(model string, region *RegionInfo) string {
 	if model == "" {
@@ -427,9 +480,6 @@ func pollVideoHistory(historyID string, refreshToken string) (map[string]interfa
 		TimeoutSeconds:    1200,
 	})
 
-	// 视频URL正则匹配模式
-	videoURLPattern := regexp.MustCompile(`https://v[0-9]+-artist\.vlabvod\.com/[^"\s]+`)
-
 	result, data, err := poller.Poll(smartPoller, func() (*poller.PollingStatus, map[string]interface{}, error) {
 		response, err := Request("POST", "/mweb/v1/get_history_by_ids", refreshToken, &RequestOptions{
 			Body: map[string]interface{}{"history_ids": []string{historyID}},
@@ -0,0 +1,81 @@
+package routes
+
+import (
+	"fmt"
+	"mime/multipart"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gloryhry/jimeng-api-go/internal/api/controllers"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/audit"
+)
+
+// newAuditEntry 根据请求上下文创建审计记录
+func newAuditEntry(c *gin.Context, token, model, prompt, negativePrompt string) *audit.Entry {
+	return &audit.Entry{
+		ClientKey:        auditClientKey(c),
+		SourceIP:         c.ClientIP(),
+		Endpoint:         c.Request.Method + " " + c.FullPath(),
+		Model:            model,
+		Prompt:           prompt,
+		NegativePrompt:   negativePrompt,
+		TokenFingerprint: audit.Fingerprint(token),
+	}
+}
+
+// finishAudit 补全生成结果并写入审计日志
+func finishAudit(entry *audit.Entry, historyID string, urls []string, err error) {
+	if entry == nil {
+		return
+	}
+	entry.HistoryID = historyID
+	entry.ResultURLs = urls
+	if err != nil {
+		entry.Outcome = audit.OutcomeFailure
+		entry.Error = err.Error()
+	} else {
+		entry.Outcome = audit.OutcomeSuccess
+	}
+	audit.Record(entry)
+}
+
+// auditClientKey 优先使用 X-Client-Key 标识调用方，否则使用 Authorization 的指纹
+func auditClientKey(c *gin.Context) string {
+	if key := c.GetHeader("X-Client-Key"); key != "" {
+		return key
+	}
+	return audit.Fingerprint(c.GetHeader("Authorization"))
+}
+
+// hashImageInputs 计算输入图片摘要，URL 等字符串输入按原文计算
+func hashImageInputs(images []interface{}) []string {
+	hashes := make([]string, 0, len(images))
+	for _, image := range images {
+		switch value := image.(type) {
+		case []byte:
+			hashes = append(hashes, audit.HashBytes(value))
+		case string:
+			hashes = append(hashes, audit.HashBytes([]byte(value)))
+		case *multipart.FileHeader:
+			hashes = append(hashes, hashFileHeader(value))
+		case *controllers.HistoryImage:
+			hashes = append(hashes, audit.HashBytes([]byte(fmt.Sprintf("%v", value.Ref))))
+		default:
+			hashes = append(hashes, audit.HashBytes([]byte(fmt.Sprintf("%v", value))))
+		}
+	}
+	return hashes
+}
+
+// hashFileHeader 流式计算上传文件的摘要
+func hashFileHeader(fh *multipart.FileHeader) string {
+	file, err := fh.Open()
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	hash, err := audit.HashReader(file)
+	if err != nil {
+		return ""
+	}
+	return hash
+}
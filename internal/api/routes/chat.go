@@ -26,16 +26,34 @@ func handleChatCompletion(c *gin.Context) {
 	if err != nil {
 		return
 	}
+	prompt := ""
+	if len(req.Messages) > 0 {
+		prompt = req.Messages[len(req.Messages)-1].Content
+	}
+	entry := newAuditEntry(c, token, req.Model, prompt, "")
 	if req.Stream {
-		stream, err := controllers.CreateCompletionStream(req.Messages, token, req.Model)
+		stream, outcome, err := controllers.CreateCompletionStream(req.Messages, token, req.Model)
 		if err != nil {
+			finishAudit(entry, "", nil, err)
 			respondError(c, err)
 			return
 		}
 		writeSSE(c, stream)
+		// 客户端提前断开时在后台读完剩余推送，生成结束、通道关闭后再按实际结果记录审计
+		go func() {
+			for range stream {
+			}
+			finishAudit(entry, outcome.HistoryID, outcome.URLs, outcome.Err)
+		}()
 		return
 	}
-	resp, err := controllers.CreateCompletion(req.Messages, token, req.Model)
+	resp, outcome, err := controllers.CreateCompletion(req.Messages, token, req.Model)
+	// 视频生成失败时响应中是说明文字而不是错误，审计仍按失败记录
+	auditErr := err
+	if auditErr == nil {
+		auditErr = outcome.Err
+	}
+	finishAudit(entry, outcome.HistoryID, outcome.URLs, auditErr)
 	if err != nil {
 		respondError(c, err)
 		return
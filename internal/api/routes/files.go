@@ -0,0 +1,171 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/logger"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/storage"
+)
+
+// RegisterFileRoutes 注册转存文件访问接口
+func RegisterFileRoutes(router *gin.RouterGroup) {
+	router.GET("/files/*filepath", handleFileDownload)
+}
+
+// RegisterFileSignRoutes 注册签名链接生成接口
+func RegisterFileSignRoutes(v1 *gin.RouterGroup) {
+	v1.POST("/files/sign", handleFileSign)
+}
+
+func handleFileDownload(c *gin.Context) {
+	local, ok := storage.Default().(*storage.LocalStorage)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未开启本地存储"})
+		return
+	}
+	key := strings.TrimPrefix(c.Param("filepath"), "/")
+	target, err := local.Resolve(key)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	sig := c.Query(storage.SignatureParam)
+	if storage.SignatureRequired() || (storage.SigningEnabled() && sig != "") {
+		if err := storage.VerifySignature(key, c.Query(storage.ExpiresParam), sig); err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if info, err := os.Stat(target); err != nil || info.IsDir() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文件不存在"})
+		return
+	}
+	c.File(target)
+}
+
+func handleFileSign(c *gin.Context) {
+	if _, err := pickToken(c); err != nil {
+		return
+	}
+	var req struct {
+		Key       string `json:"key"`
+		URL       string `json:"url"`
+		ExpiresIn int    `json:"expires_in"` // 秒，为空时使用默认有效期
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	local, ok := storage.Default().(*storage.LocalStorage)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未开启本地存储"})
+		return
+	}
+	if !storage.SigningEnabled() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "未配置签名密钥 storage.signing.secret"})
+		return
+	}
+	if req.ExpiresIn < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_in 不能为负数"})
+		return
+	}
+	key := req.Key
+	if key == "" && req.URL != "" {
+		key = fileKeyFromURL(req.URL)
+	}
+	if key == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "需要提供 key 或 url"})
+		return
+	}
+	target, err := local.Resolve(key)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if info, err := os.Stat(target); err != nil || info.IsDir() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文件不存在"})
+		return
+	}
+	link, expiresAt := local.SignedURL(key, time.Duration(req.ExpiresIn)*time.Second)
+	c.JSON(http.StatusOK, gin.H{
+		"key":        key,
+		"url":        absoluteURL(c, link),
+		"expires_at": expiresAt.Unix(),
+	})
+}
+
+// fileKeyFromURL 从 /files/ 链接中提取存储 key
+func fileKeyFromURL(link string) string {
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	idx := strings.Index(parsed.Path, storage.FileRoutePrefix)
+	if idx < 0 {
+		return ""
+	}
+	return parsed.Path[idx+len(storage.FileRoutePrefix):]
+}
+
+// mirrorResults 转存生成结果，失败时记录日志并返回 nil，调用方继续使用上游链接
+func mirrorResults(c *gin.Context, meta storage.MirrorMeta, urls []string) []string {
+	return objectURLs(c, mirrorObjects(meta, urls))
+}
+
+// mirrorObjects 转存生成结果并返回存储对象，失败时记录日志并返回 nil
+func mirrorObjects(meta storage.MirrorMeta, urls []string) []*storage.Object {
+	if !storage.Enabled() || len(urls) == 0 {
+		return nil
+	}
+	objects, err := storage.MirrorURLs(urls, meta)
+	if err != nil {
+		logger.Warn(fmt.Sprintf("结果转存失败，将返回上游链接: %v", err))
+		return nil
+	}
+	return objects
+}
+
+// objectURLs 返回存储对象的完整访问链接
+func objectURLs(c *gin.Context, objects []*storage.Object) []string {
+	if len(objects) == 0 {
+		return nil
+	}
+	localURLs := make([]string, len(objects))
+	for i, obj := range objects {
+		localURLs[i] = absoluteURL(c, obj.URL)
+	}
+	return localURLs
+}
+
+// applyLocalURL 根据响应模式将本地链接写入结果项
+func applyLocalURL(item map[string]string, localURL string) {
+	if localURL == "" {
+		return
+	}
+	if _, hasURL := item["url"]; hasURL && storage.ResponseMode() == storage.ResponseModeLocal {
+		item["url"] = localURL
+		return
+	}
+	item["local_url"] = localURL
+}
+
+// absoluteURL 为相对路径补全当前请求的协议和主机
+func absoluteURL(c *gin.Context, link string) string {
+	if strings.HasPrefix(link, "http://") || strings.HasPrefix(link, "https://") {
+		return link
+	}
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, c.Request.Host, link)
+}
@@ -28,3 +28,16 @@ func respondError(c *gin.Context, err error) {
 	}
 	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 }
+
+// pickTokens 返回打乱顺序的全部 token，按 n 多次提交时轮流使用
+func pickTokens(c *gin.Context) ([]string, error) {
+	tokens := controllers.TokenSplit(c.GetHeader("Authorization"))
+	if len(tokens) == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "缺少 Authorization"})
+		return nil, errUnauthorized
+	}
+	rand.Shuffle(len(tokens), func(i, j int) {
+		tokens[i], tokens[j] = tokens[j], tokens[i]
+	})
+	return tokens, nil
+}
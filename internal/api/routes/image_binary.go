@@ -0,0 +1,137 @@
+package routes
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gloryhry/jimeng-api-go/internal/api/controllers"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/utils"
+)
+
+// responseFormatBinary 直接返回图片内容而不是 JSON
+const responseFormatBinary = "binary"
+
+// 多张图片的打包方式
+const (
+	archiveZip       = "zip"
+	archiveMultipart = "multipart"
+)
+
+// binaryImage 已下载的图片内容
+type binaryImage struct {
+	Filename string
+	MimeType string
+	Content  []byte
+}
+
+// writeBinaryImages 以二进制返回结果：单张直接返回图片，多张按 zip 或 multipart/mixed 打包
+// 打包方式优先取查询参数 archive，其次根据 Accept 头判断，默认 zip
+func writeBinaryImages(c *gin.Context, result *controllers.ImageResult, output *imageOutput) {
+	urls := result.URLs
+	if len(urls) == 0 {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "没有可返回的图片"})
+		return
+	}
+
+	name := result.HistoryID
+	if name == "" {
+		name = utils.UUID(false)
+	}
+	images := make([]binaryImage, 0, len(urls))
+	for i, url := range urls {
+		content, mimeType, err := fetchImageResult(i, url, output)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		images = append(images, binaryImage{
+			Filename: fmt.Sprintf("%s_%d.%s", name, i, utils.ImageExtension(mimeType)),
+			MimeType: mimeType,
+			Content:  content,
+		})
+	}
+
+	if len(images) == 1 {
+		c.Header("Content-Disposition", fmt.Sprintf("inline; filename=\"%s\"", images[0].Filename))
+		c.Data(http.StatusOK, images[0].MimeType, images[0].Content)
+		return
+	}
+
+	if binaryArchive(c) == archiveMultipart {
+		body, contentType, err := buildMultipartMixed(images)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		c.Data(http.StatusOK, contentType, body)
+		return
+	}
+	body, err := buildZipArchive(images)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.zip\"", name))
+	c.Data(http.StatusOK, "application/zip", body)
+}
+
+// binaryArchive 判断多张图片的打包方式
+func binaryArchive(c *gin.Context) string {
+	switch strings.ToLower(c.Query("archive")) {
+	case archiveMultipart:
+		return archiveMultipart
+	case archiveZip:
+		return archiveZip
+	}
+	if strings.Contains(c.GetHeader("Accept"), "multipart/mixed") {
+		return archiveMultipart
+	}
+	return archiveZip
+}
+
+// buildZipArchive 打包为 zip，图片本身已压缩，使用 Store 方式避免重复压缩
+func buildZipArchive(images []binaryImage) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for _, img := range images {
+		entry, err := writer.CreateHeader(&zip.FileHeader{Name: img.Filename, Method: zip.Store})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := entry.Write(img.Content); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// buildMultipartMixed 打包为 multipart/mixed，返回内容和带 boundary 的 Content-Type
+func buildMultipartMixed(images []binaryImage) ([]byte, string, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	for _, img := range images {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", img.MimeType)
+		header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", img.Filename))
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := part.Write(img.Content); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), "multipart/mixed; boundary=" + writer.Boundary(), nil
+}
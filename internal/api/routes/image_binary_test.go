@@ -0,0 +1,177 @@
+package routes
+
+import (
+	"archive/zip"
+	"bytes"
+	"image"
+	"image/png"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gloryhry/jimeng-api-go/internal/api/controllers"
+)
+
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// serveImages 启动返回测试图片的服务，路径 /0、/1… 对应 images 下标，其余返回 404
+func serveImages(t *testing.T, images ...[]byte) (*httptest.Server, []string) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i, img := range images {
+			if r.URL.Path == "/"+strconv.Itoa(i) {
+				w.Write(img)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(server.Close)
+	urls := make([]string, len(images))
+	for i := range images {
+		urls[i] = server.URL + "/" + strconv.Itoa(i)
+	}
+	return server, urls
+}
+
+func runBinaryImages(target, accept string, result *controllers.ImageResult) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, target, nil)
+	if accept != "" {
+		c.Request.Header.Set("Accept", accept)
+	}
+	writeBinaryImages(c, result, nil)
+	return recorder
+}
+
+func TestWriteBinaryImagesSingle(t *testing.T) {
+	img := testPNG(t, 4, 3)
+	_, urls := serveImages(t, img)
+	recorder := runBinaryImages("/v1/images/generations", "", &controllers.ImageResult{HistoryID: "h1", URLs: urls})
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", recorder.Code, recorder.Body.String())
+	}
+	if got := recorder.Header().Get("Content-Type"); got != "image/png" {
+		t.Errorf("Content-Type = %s, want image/png", got)
+	}
+	if got := recorder.Header().Get("Content-Disposition"); got != `inline; filename="h1_0.png"` {
+		t.Errorf("Content-Disposition = %s", got)
+	}
+	if !bytes.Equal(recorder.Body.Bytes(), img) {
+		t.Error("body does not match source image")
+	}
+}
+
+func TestWriteBinaryImagesZip(t *testing.T) {
+	images := [][]byte{testPNG(t, 4, 3), testPNG(t, 2, 2)}
+	_, urls := serveImages(t, images...)
+	recorder := runBinaryImages("/v1/images/generations", "", &controllers.ImageResult{HistoryID: "h1", URLs: urls})
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", recorder.Code, recorder.Body.String())
+	}
+	if got := recorder.Header().Get("Content-Type"); got != "application/zip" {
+		t.Fatalf("Content-Type = %s, want application/zip", got)
+	}
+	reader, err := zip.NewReader(bytes.NewReader(recorder.Body.Bytes()), int64(recorder.Body.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reader.File) != len(images) {
+		t.Fatalf("zip entries = %d, want %d", len(reader.File), len(images))
+	}
+	for i, file := range reader.File {
+		if want := "h1_" + strconv.Itoa(i) + ".png"; file.Name != want {
+			t.Errorf("entry %d name = %s, want %s", i, file.Name, want)
+		}
+		if file.Method != zip.Store {
+			t.Errorf("entry %d method = %d, want Store", i, file.Method)
+		}
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		if !bytes.Equal(content, images[i]) {
+			t.Errorf("entry %d content mismatch", i)
+		}
+	}
+}
+
+func TestWriteBinaryImagesMultipart(t *testing.T) {
+	images := [][]byte{testPNG(t, 4, 3), testPNG(t, 2, 2)}
+	_, urls := serveImages(t, images...)
+	tests := []struct {
+		name   string
+		target string
+		accept string
+	}{
+		{name: "archive query", target: "/v1/images/generations?archive=multipart"},
+		{name: "accept header", target: "/v1/images/generations", accept: "multipart/mixed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := runBinaryImages(tt.target, tt.accept, &controllers.ImageResult{HistoryID: "h1", URLs: urls})
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("status = %d, body = %s", recorder.Code, recorder.Body.String())
+			}
+			mediaType, params, err := mime.ParseMediaType(recorder.Header().Get("Content-Type"))
+			if err != nil || mediaType != "multipart/mixed" {
+				t.Fatalf("Content-Type = %s, err = %v", recorder.Header().Get("Content-Type"), err)
+			}
+			reader := multipart.NewReader(recorder.Body, params["boundary"])
+			for i := range images {
+				part, err := reader.NextPart()
+				if err != nil {
+					t.Fatalf("part %d: %v", i, err)
+				}
+				if got := part.Header.Get("Content-Type"); got != "image/png" {
+					t.Errorf("part %d Content-Type = %s", i, got)
+				}
+				content, _ := io.ReadAll(part)
+				if !bytes.Equal(content, images[i]) {
+					t.Errorf("part %d content mismatch", i)
+				}
+			}
+			if _, err := reader.NextPart(); err != io.EOF {
+				t.Errorf("expected %d parts, got more (err = %v)", len(images), err)
+			}
+		})
+	}
+}
+
+func TestWriteBinaryImagesErrors(t *testing.T) {
+	server, _ := serveImages(t)
+	tests := []struct {
+		name   string
+		urls   []string
+		status int
+	}{
+		{name: "no images", status: http.StatusBadGateway},
+		{name: "download failure", urls: []string{server.URL + "/missing"}, status: http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := runBinaryImages("/v1/images/generations", "", &controllers.ImageResult{URLs: tt.urls})
+			if recorder.Code != tt.status {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.status)
+			}
+		})
+	}
+}
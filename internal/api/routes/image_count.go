@@ -0,0 +1,137 @@
+package routes
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gloryhry/jimeng-api-go/internal/api/controllers"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/config"
+)
+
+// parseImageCount 校验 n，未指定时返回 0
+func parseImageCount(n *int) (int, error) {
+	if n == nil {
+		return 0, nil
+	}
+	if err := checkCountRange("n", *n, config.System.ImageMaxCount); err != nil {
+		return 0, err
+	}
+	return *n, nil
+}
+
+// generateImageCount 未指定 n 时只提交一次，否则并发提交直到凑够 n 张
+func generateImageCount(count int, perSubmission int, tokens []string, generate func(token string) (*controllers.ImageResult, error)) (*controllers.ImageResult, error) {
+	if count == 0 {
+		return generate(tokens[0])
+	}
+	return controllers.CollectImages(count, perSubmission, tokens, generate)
+}
+
+// tokenUploads 为凑够 n 张而多次提交时，每个 token 只上传一次输入图片，各次提交复用得到的 URI
+// 上传结果只对上传它的账号可用，不同 token 各自上传
+type tokenUploads struct {
+	images  []interface{}
+	upload  func(images []interface{}, token string) ([]interface{}, error)
+	mu      sync.Mutex
+	uploads map[string]*tokenUpload
+}
+
+type tokenUpload struct {
+	once   sync.Once
+	images []interface{}
+	err    error
+}
+
+func newTokenUploads(images []interface{}) *tokenUploads {
+	return &tokenUploads{images: images, upload: controllers.UploadImages, uploads: make(map[string]*tokenUpload)}
+}
+
+// get 返回 token 对应的已上传图片，首次调用时上传，并发调用等待同一次上传完成
+func (u *tokenUploads) get(token string) ([]interface{}, error) {
+	u.mu.Lock()
+	upload, ok := u.uploads[token]
+	if !ok {
+		upload = &tokenUpload{}
+		u.uploads[token] = upload
+	}
+	u.mu.Unlock()
+	upload.once.Do(func() {
+		upload.images, upload.err = u.upload(u.images, token)
+	})
+	return upload.images, upload.err
+}
+
+// applyImageCountReport 指定了 n 时在响应中报告请求数量，未凑够时标记 partial 并附带失败原因
+func applyImageCountReport(resp gin.H, result *controllers.ImageResult) {
+	if result.Requested == 0 {
+		return
+	}
+	resp["requested"] = result.Requested
+	if result.Partial() {
+		resp["partial"] = true
+		if len(result.Failures) > 0 {
+			resp["errors"] = result.Failures
+		}
+	}
+}
+
+// setImageCountHeaders 二进制响应通过响应头报告请求数量和部分完成情况
+func setImageCountHeaders(c *gin.Context, result *controllers.ImageResult) {
+	if result.Requested == 0 {
+		return
+	}
+	c.Header("X-Images-Requested", strconv.Itoa(result.Requested))
+	if result.Partial() {
+		c.Header("X-Images-Partial", "true")
+		c.Header("X-Images-Errors", strconv.Itoa(len(result.Failures)))
+	}
+}
+
+// parseMultiImageCount 校验 multi_image 与 count，返回多图张数，0 表示未指定（由模型配置的默认张数决定）
+func parseMultiImageCount(multiImage *bool, count *int) (int, error) {
+	if count == nil {
+		return 0, nil
+	}
+	if multiImage != nil && !*multiImage {
+		return 0, fmt.Errorf("count 仅在 multi_image 为 true 时可用")
+	}
+	if err := checkCountRange("count", *count, config.System.MultiImage.MaxCount); err != nil {
+		return 0, err
+	}
+	return *count, nil
+}
+
+// applyMultiImageReport 多图模式下报告每次提交请求的张数和实际产出的张数
+func applyMultiImageReport(resp gin.H, result *controllers.ImageResult) {
+	if result.MultiImage == 0 {
+		return
+	}
+	resp["multi_image"] = true
+	resp["count"] = result.MultiImage
+	resp["produced"] = len(result.URLs)
+}
+
+// setMultiImageHeader 二进制响应通过响应头报告多图模式实际产出的张数
+func setMultiImageHeader(c *gin.Context, result *controllers.ImageResult) {
+	if result.MultiImage == 0 {
+		return
+	}
+	c.Header("X-Images-Multi-Count", strconv.Itoa(result.MultiImage))
+	c.Header("X-Images-Produced", strconv.Itoa(len(result.URLs)))
+}
+
+// checkCountRange 校验数量不小于 1 且不超过上限，maxCount <= 0 表示不限制上限
+func checkCountRange(field string, value int, maxCount int) error {
+	if maxCount <= 0 {
+		if value < 1 {
+			return fmt.Errorf("%s 必须大于等于 1", field)
+		}
+		return nil
+	}
+	if value < 1 || value > maxCount {
+		return fmt.Errorf("%s 必须在 1 到 %d 之间", field, maxCount)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package routes
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/config"
+)
+
+func TestParseImageCount(t *testing.T) {
+	previous := config.System
+	t.Cleanup(func() { config.System = previous })
+
+	intPtr := func(n int) *int { return &n }
+	tests := []struct {
+		name     string
+		n        *int
+		maxCount int
+		want     int
+		wantErr  string
+	}{
+		{name: "not specified", maxCount: 10},
+		{name: "within limit", n: intPtr(3), maxCount: 10, want: 3},
+		{name: "over limit", n: intPtr(11), maxCount: 10, wantErr: "n 必须在 1 到 10 之间"},
+		{name: "zero", n: intPtr(0), maxCount: 10, wantErr: "n 必须在 1 到 10 之间"},
+		{name: "no upper limit", n: intPtr(100), want: 100},
+		{name: "zero without upper limit", n: intPtr(0), wantErr: "n 必须大于等于 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.System = &config.SystemConfig{ImageMaxCount: tt.maxCount}
+			got, err := parseImageCount(tt.n)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("parseImageCount() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("parseImageCount() = %d, %v, want %d", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMultiImageCount(t *testing.T) {
+	previous := config.System
+	t.Cleanup(func() { config.System = previous })
+
+	intPtr := func(n int) *int { return &n }
+	disabled := false
+	tests := []struct {
+		name       string
+		multiImage *bool
+		count      *int
+		maxCount   int
+		want       int
+		wantErr    string
+	}{
+		{name: "not specified", maxCount: 6},
+		{name: "within limit", count: intPtr(3), maxCount: 6, want: 3},
+		{name: "over limit", count: intPtr(7), maxCount: 6, wantErr: "count 必须在 1 到 6 之间"},
+		{name: "no upper limit", count: intPtr(12), want: 12},
+		{name: "zero without upper limit", count: intPtr(0), wantErr: "count 必须大于等于 1"},
+		{name: "multi image disabled", multiImage: &disabled, count: intPtr(2), maxCount: 6, wantErr: "count 仅在 multi_image 为 true 时可用"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.System = &config.SystemConfig{MultiImage: config.MultiImageConfig{MaxCount: tt.maxCount}}
+			got, err := parseMultiImageCount(tt.multiImage, tt.count)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("parseMultiImageCount() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("parseMultiImageCount() = %d, %v, want %d", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenUploads(t *testing.T) {
+	uploads := newTokenUploads([]interface{}{"a", "b"})
+	var mu sync.Mutex
+	calls := map[string]int{}
+	uploads.upload = func(images []interface{}, token string) ([]interface{}, error) {
+		mu.Lock()
+		calls[token]++
+		mu.Unlock()
+		return []interface{}{token + "/a", token + "/b"}, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		token := []string{"t1", "t2"}[i%2]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := uploads.get(token)
+			if err != nil || len(got) != 2 || got[0] != token+"/a" {
+				t.Errorf("get(%s) = %v, %v", token, got, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls["t1"] != 1 || calls["t2"] != 1 {
+		t.Errorf("upload calls = %v, want one per token", calls)
+	}
+}
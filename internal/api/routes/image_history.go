@@ -0,0 +1,67 @@
+package routes
+
+import (
+	"fmt"
+
+	"github.com/gloryhry/jimeng-api-go/internal/api/controllers"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/errors"
+)
+
+// findHistoryImage 依次用各 token 查询引用的历史结果，返回图片和能查到它的 token
+// 历史记录只对生成它的账号可见，后续提交需要使用同一个 token
+func findHistoryImage(tokens []string, ref controllers.HistoryImageRef) (*controllers.HistoryImage, string, error) {
+	var firstErr error
+	for _, token := range tokens {
+		image, err := controllers.GetHistoryImage(ref, token)
+		if err == nil {
+			return image, token, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return nil, "", firstErr
+}
+
+// pinHistoryTokens 在提交前一次性解析输入中的所有历史结果引用，并原地替换为查到的 *controllers.HistoryImage，
+// 后续各次提交直接复用其 URI；所有引用必须属于同一账号，返回只含该账号的 token，没有引用时原样返回
+func pinHistoryTokens(tokens []string, images []interface{}) ([]string, error) {
+	pinnedBy := ""
+	for i, image := range images {
+		ref, ok := image.(controllers.HistoryImageRef)
+		if !ok {
+			continue
+		}
+		historyImage, token, err := findHistoryImage(tokens, ref)
+		if err != nil {
+			if pinnedBy != "" {
+				return nil, errors.ErrAPIRequestParamsInvalid(
+					fmt.Sprintf("引用的历史结果必须属于同一账号，%s 无法用 %s 所属账号查询: %v", ref.HistoryID, pinnedBy, err),
+				)
+			}
+			return nil, err
+		}
+		images[i] = historyImage
+		tokens, pinnedBy = []string{token}, ref.HistoryID
+	}
+	return tokens, nil
+}
+
+// historyImageURL 将历史结果（引用或已解析的图片）替换为图片链接，供需要读取原图内容的接口使用；其他输入原样返回
+func historyImageURL(tokens []string, image interface{}) (interface{}, error) {
+	historyImage, ok := image.(*controllers.HistoryImage)
+	if ref, isRef := image.(controllers.HistoryImageRef); isRef {
+		var err error
+		if historyImage, _, err = findHistoryImage(tokens, ref); err != nil {
+			return nil, err
+		}
+	} else if !ok {
+		return image, nil
+	}
+	if historyImage.URL == "" {
+		return nil, errors.ErrAPIImageGenerationFailed(
+			fmt.Sprintf("历史记录 %s 第 %d 张图片缺少链接", historyImage.Ref.HistoryID, historyImage.Ref.ItemIndex),
+		)
+	}
+	return historyImage.URL, nil
+}
@@ -0,0 +1,42 @@
+package routes
+
+import (
+	"testing"
+
+	"github.com/gloryhry/jimeng-api-go/internal/api/controllers"
+)
+
+func TestHistoryImageURL(t *testing.T) {
+	ref := controllers.HistoryImageRef{HistoryID: "h1", ItemIndex: 1}
+	tests := []struct {
+		name    string
+		image   interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "resolved history image", image: &controllers.HistoryImage{Ref: ref, URI: "tos/x", URL: "https://example.com/x.png"}, want: "https://example.com/x.png"},
+		{name: "resolved without url", image: &controllers.HistoryImage{Ref: ref, URI: "tos/x"}, wantErr: true},
+		{name: "plain input", image: "https://example.com/y.png", want: "https://example.com/y.png"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 已解析的输入不会再查询历史记录，tokens 为空也不影响
+			got, err := historyImageURL(nil, tt.image)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("historyImageURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("historyImageURL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPinHistoryTokensWithoutRefs(t *testing.T) {
+	tokens := []string{"a", "b"}
+	images := []interface{}{"https://example.com/y.png", &controllers.HistoryImage{URI: "tos/x"}}
+	got, err := pinHistoryTokens(tokens, images)
+	if err != nil || len(got) != 2 {
+		t.Fatalf("pinHistoryTokens() = %v, %v, want tokens unchanged", got, err)
+	}
+}
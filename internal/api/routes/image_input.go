@@ -0,0 +1,79 @@
+package routes
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gloryhry/jimeng-api-go/internal/api/controllers"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/uploader"
+)
+
+// parseImageInputs 解析 JSON 请求中的图片输入，每一项可以是 URL、data URI、BASE64、本地素材 ID 字符串，
+// {"url": "..."} 对象，OpenAI 风格的 {"image_url": {"url": "..."}} / {"image_url": "..."} 对象，
+// 或引用已生成结果的 {"history_id": "...", "item_index": 0}（解析为 controllers.HistoryImageRef）
+func parseImageInputs(field string, raw []interface{}) ([]interface{}, error) {
+	images := make([]interface{}, 0, len(raw))
+	for i, item := range raw {
+		if ref, ok, err := historyImageRef(item); ok {
+			if err != nil {
+				return nil, fmt.Errorf("%s[%d] %v", field, i, err)
+			}
+			images = append(images, ref)
+			continue
+		}
+		value, ok := imageInputValue(item)
+		if !ok {
+			return nil, fmt.Errorf("%s[%d] 格式无效，应为字符串、{\"url\"} 或 {\"image_url\":{\"url\"}}", field, i)
+		}
+		if err := uploader.ValidateStringInput(value); err != nil {
+			return nil, fmt.Errorf("%s[%d] %v", field, i, err)
+		}
+		images = append(images, value)
+	}
+	return images, nil
+}
+
+func imageInputValue(item interface{}) (string, bool) {
+	switch value := item.(type) {
+	case string:
+		return strings.TrimSpace(value), true
+	case map[string]interface{}:
+		if url, ok := value["url"].(string); ok {
+			return strings.TrimSpace(url), true
+		}
+		switch imageURL := value["image_url"].(type) {
+		case string:
+			return strings.TrimSpace(imageURL), true
+		case map[string]interface{}:
+			if url, ok := imageURL["url"].(string); ok {
+				return strings.TrimSpace(url), true
+			}
+		}
+	}
+	return "", false
+}
+
+// historyImageRef 解析 {"history_id", "item_index"} 引用，item_index 省略时为 0
+func historyImageRef(item interface{}) (controllers.HistoryImageRef, bool, error) {
+	value, ok := item.(map[string]interface{})
+	if !ok {
+		return controllers.HistoryImageRef{}, false, nil
+	}
+	rawID, ok := value["history_id"]
+	if !ok {
+		return controllers.HistoryImageRef{}, false, nil
+	}
+	historyID, _ := rawID.(string)
+	ref := controllers.HistoryImageRef{HistoryID: strings.TrimSpace(historyID)}
+	if ref.HistoryID == "" {
+		return ref, true, fmt.Errorf("history_id 必须是非空字符串")
+	}
+	if rawIndex, ok := value["item_index"]; ok {
+		index, ok := rawIndex.(float64)
+		if !ok || index < 0 || index != float64(int(index)) {
+			return ref, true, fmt.Errorf("item_index 必须是非负整数")
+		}
+		ref.ItemIndex = int(index)
+	}
+	return ref, true, nil
+}
@@ -0,0 +1,86 @@
+package routes
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gloryhry/jimeng-api-go/internal/api/builders"
+	"github.com/gloryhry/jimeng-api-go/internal/api/controllers"
+)
+
+// handleImageOutpaint 扩图接口: 单张图片按目标比例（或各边像素）扩展画布，提示词可选
+func handleImageOutpaint(c *gin.Context) {
+	tokens, err := pickTokens(c)
+	if err != nil {
+		return
+	}
+	var reqBody struct {
+		singleImageRequest
+		Padding *builders.OutpaintPadding `json:"padding"`
+	}
+	req := &reqBody.singleImageRequest
+	parseForm := func() (err error) {
+		reqBody.Padding, err = parseOutpaintPadding(c)
+		return err
+	}
+	if !bindSingleImageRequest(c, &reqBody, req, true, parseForm) {
+		return
+	}
+	images := []interface{}{req.image}
+	if tokens, err = pinHistoryTokens(tokens, images); err != nil {
+		respondError(c, err)
+		return
+	}
+	seeds := seedSequence(req.seed)
+	// 未指定 ratio 时 size 映射为目标比例
+	source, err := controllers.PrepareOutpaint(images[0], req.ratio, reqBody.Padding)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	options := &controllers.ImageOptions{
+		Resolution:     req.resolution,
+		SampleStrength: req.SampleStrength,
+	}
+	entry := newAuditEntry(c, tokens[0], req.Model, req.Prompt, "")
+	entry.InputImageHashes = hashImageInputs(images)
+	result, err := generateImageCount(req.count, controllers.ImagesPerComposition, tokens, func(token string) (*controllers.ImageResult, error) {
+		opts := *options
+		opts.Seed = seeds()
+		return controllers.GenerateImageOutpaint(req.Model, req.Prompt, source, &opts, token)
+	})
+	finishAudit(entry, imageHistoryID(result), imageURLs(result), err)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	req.writeResult(c, result, gin.H{"padding": source.Padding})
+}
+
+// parseOutpaintPadding 读取 multipart 中的 padding_top/bottom/left/right，全部为空时返回 nil
+func parseOutpaintPadding(c *gin.Context) (*builders.OutpaintPadding, error) {
+	var padding builders.OutpaintPadding
+	provided := false
+	for _, side := range []struct {
+		field string
+		value *int
+	}{
+		{"padding_top", &padding.Top},
+		{"padding_bottom", &padding.Bottom},
+		{"padding_left", &padding.Left},
+		{"padding_right", &padding.Right},
+	} {
+		n, err := parseOptionalInt(c.PostForm(side.field))
+		if err != nil {
+			return nil, fmt.Errorf("%s 必须是整数", side.field)
+		}
+		if n != nil {
+			*side.value = *n
+			provided = true
+		}
+	}
+	if !provided {
+		return nil, nil
+	}
+	return &padding, nil
+}
@@ -0,0 +1,101 @@
+package routes
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	apiErrors "github.com/gloryhry/jimeng-api-go/internal/pkg/errors"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/logger"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/storage"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/utils"
+)
+
+// imageOutput 图片输出格式参数（OpenAI output_format / output_compression）
+type imageOutput struct {
+	Format      string // png 或 jpeg，为空时保持上游格式
+	Compression int    // 0-100，jpeg 的质量参数
+}
+
+// parseImageOutput 校验输出格式参数，转码需要服务端下载结果，因此要求 b64_json 或开启结果转存
+func parseImageOutput(format string, compression *int, responseFormat string) (*imageOutput, error) {
+	output := &imageOutput{Compression: 100}
+	if compression != nil {
+		if *compression < 0 || *compression > 100 {
+			return nil, fmt.Errorf("output_compression 必须在 0-100 之间")
+		}
+		output.Compression = *compression
+	}
+	if strings.TrimSpace(format) == "" {
+		return output, nil
+	}
+	output.Format = utils.NormalizeOutputFormat(format)
+	if output.Format == "" {
+		return nil, fmt.Errorf("不支持的 output_format \"%s\"，可选值: %s", format, strings.Join(utils.OutputImageFormats, ", "))
+	}
+	if defaultResponseFormat(responseFormat) == "url" && !storage.Enabled() {
+		return nil, fmt.Errorf("output_format 需要 response_format=b64_json 或开启结果转存")
+	}
+	return output, nil
+}
+
+// parseOptionalInt 解析表单中的可选整数
+func parseOptionalInt(value string) (*int, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// fetchImageResult 下载单张结果并按需转码，返回内容和 MIME 类型
+func fetchImageResult(index int, url string, output *imageOutput) ([]byte, string, error) {
+	content, err := utils.FetchFile(url)
+	if err != nil {
+		logger.Error(fmt.Sprintf("下载图片失败 (第%d张): %v", index+1, err))
+		return nil, "", apiErrors.ErrAPIRequestFailed(
+			fmt.Sprintf("下载图片失败: %v", err),
+		).SetHTTPStatusCode(502)
+	}
+	mimeType := utils.ImageMimeType(utils.SniffImageFormat(content))
+	if output != nil && output.Format != "" {
+		content, mimeType, err = utils.ConvertImage(content, output.Format, output.Compression)
+		if err != nil {
+			logger.Error(fmt.Sprintf("图片转码失败 (第%d张): %v", index+1, err))
+			return nil, "", apiErrors.ErrAPIRequestFailed(fmt.Sprintf("图片转码失败: %v", err))
+		}
+	}
+	return content, mimeType, nil
+}
+
+// downloadImageResults 下载结果并按需转码，用于 b64_json 和指定了 output_format 的转存
+func downloadImageResults(c *gin.Context, meta storage.MirrorMeta, urls []string, format string, output *imageOutput) ([]map[string]string, error) {
+	data := make([]map[string]string, 0, len(urls))
+	for i, url := range urls {
+		content, mimeType, err := fetchImageResult(i, url, output)
+		if err != nil {
+			return nil, err
+		}
+		item := map[string]string{"mime_type": mimeType}
+		if format == "b64_json" {
+			item["b64_json"] = base64.StdEncoding.EncodeToString(content)
+		} else {
+			item["url"] = url
+		}
+		if storage.Enabled() {
+			obj, err := storage.SaveBytes(content, mimeType, meta, i)
+			if err != nil {
+				logger.Warn(fmt.Sprintf("结果转存失败，将返回上游链接: %v", err))
+			} else {
+				applyLocalURL(item, absoluteURL(c, obj.URL))
+			}
+		}
+		data = append(data, item)
+	}
+	return data, nil
+}
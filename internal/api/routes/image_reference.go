@@ -0,0 +1,91 @@
+package routes
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gloryhry/jimeng-api-go/internal/api/builders"
+)
+
+// parseBlendImages 解析图生图的 images，每一项可以是普通图片输入，也可以是 {"image": ..., "role": ..., "strength": ...}
+// 全部未指定 role 和 strength 时返回的参考图设置为 nil
+func parseBlendImages(raw []interface{}) ([]interface{}, []builders.BlendReference, error) {
+	inputs := make([]interface{}, len(raw))
+	references := make([]builders.BlendReference, len(raw))
+	annotated := false
+	for i, item := range raw {
+		inputs[i] = item
+		value, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if image, ok := value["image"]; ok {
+			inputs[i] = image
+		}
+		reference, err := blendReference(value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("images[%d] %v", i, err)
+		}
+		if reference != (builders.BlendReference{}) {
+			references[i], annotated = reference, true
+		}
+	}
+	images, err := parseImageInputs("images", inputs)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !annotated {
+		return images, nil, nil
+	}
+	return images, references, nil
+}
+
+func blendReference(value map[string]interface{}) (builders.BlendReference, error) {
+	var reference builders.BlendReference
+	if raw, ok := value["role"]; ok {
+		role, ok := raw.(string)
+		if !ok {
+			return reference, fmt.Errorf("role 必须是字符串")
+		}
+		reference.Role = strings.ToLower(strings.TrimSpace(role))
+	}
+	if raw, ok := value["strength"]; ok {
+		strength, ok := raw.(float64)
+		if !ok {
+			return reference, fmt.Errorf("strength 必须是数字")
+		}
+		reference.Strength = strength
+	}
+	return reference, reference.Validate()
+}
+
+// parseBlendReferenceForm 读取 multipart 中与 images 文件顺序对应的 roles 和 strengths 字段，留空表示该图片不指定
+func parseBlendReferenceForm(c *gin.Context, count int) ([]builders.BlendReference, error) {
+	roles := c.PostFormArray("roles")
+	strengths := c.PostFormArray("strengths")
+	if len(roles) == 0 && len(strengths) == 0 {
+		return nil, nil
+	}
+	if (len(roles) > 0 && len(roles) != count) || (len(strengths) > 0 && len(strengths) != count) {
+		return nil, fmt.Errorf("roles 和 strengths 的数量必须与图片数量 %d 一致", count)
+	}
+	references := make([]builders.BlendReference, count)
+	for i := range references {
+		if i < len(roles) {
+			references[i].Role = strings.ToLower(strings.TrimSpace(roles[i]))
+		}
+		if i < len(strengths) && strings.TrimSpace(strengths[i]) != "" {
+			strength, err := strconv.ParseFloat(strings.TrimSpace(strengths[i]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("strengths[%d] 必须是数字", i)
+			}
+			references[i].Strength = strength
+		}
+		if err := references[i].Validate(); err != nil {
+			return nil, fmt.Errorf("images[%d] %v", i, err)
+		}
+	}
+	return references, nil
+}
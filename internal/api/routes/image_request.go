@@ -0,0 +1,110 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gloryhry/jimeng-api-go/internal/api/controllers"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/utils"
+)
+
+// imageRequest 各图片接口共用的模型、尺寸、输出、数量和种子参数，接口的请求体嵌入该结构体再追加自己的字段
+type imageRequest struct {
+	Model          string  `json:"model"`
+	Size           string  `json:"size"`
+	Quality        string  `json:"quality"`
+	Ratio          string  `json:"ratio"`
+	Resolution     string  `json:"resolution"`
+	SampleStrength float64 `json:"sample_strength"`
+	ResponseFormat string  `json:"response_format"`
+	OutputFormat   string  `json:"output_format"`
+	Compression    *int    `json:"output_compression"`
+	N              *int    `json:"n"`
+	Seed           *int64  `json:"seed"`
+
+	// 以下字段由 validate 填充，inputInfo 由接口在读取上传文件时追加
+	ratio      string
+	resolution string
+	output     *imageOutput
+	count      int
+	seed       int64
+	inputInfo  []gin.H
+}
+
+// readForm 读取 multipart 表单中的公共字段
+func (r *imageRequest) readForm(c *gin.Context) error {
+	r.Model = c.PostForm("model")
+	r.Size = c.PostForm("size")
+	r.Quality = c.PostForm("quality")
+	r.Ratio = c.PostForm("ratio")
+	r.Resolution = c.PostForm("resolution")
+	r.SampleStrength = parseFloat(c.PostForm("sample_strength"))
+	r.ResponseFormat = c.PostForm("response_format")
+	r.OutputFormat = c.PostForm("output_format")
+	var err error
+	if r.Compression, err = parseOptionalInt(c.PostForm("output_compression")); err != nil {
+		return fmt.Errorf("output_compression 必须是整数")
+	}
+	if r.N, err = parseOptionalInt(c.PostForm("n")); err != nil {
+		return fmt.Errorf("n 必须是整数")
+	}
+	if r.Seed, err = parseOptionalInt64(c.PostForm("seed")); err != nil {
+		return fmt.Errorf("seed 必须是整数")
+	}
+	return nil
+}
+
+// validate 校验公共字段，解析出比例、分辨率、输出格式、生成数量和种子
+func (r *imageRequest) validate() error {
+	var err error
+	if r.output, err = parseImageOutput(r.OutputFormat, r.Compression, r.ResponseFormat); err != nil {
+		return err
+	}
+	if r.count, err = parseImageCount(r.N); err != nil {
+		return err
+	}
+	if r.seed, err = parseSeed(r.Seed); err != nil {
+		return err
+	}
+	r.ratio, r.resolution, err = resolveImageDimensions(r.Size, r.Quality, r.Ratio, r.Resolution)
+	return err
+}
+
+// writeResult 按请求的响应格式写出生成结果，上传了文件时附带 input_image_info
+func (r *imageRequest) writeResult(c *gin.Context, result *controllers.ImageResult, extra gin.H) {
+	if len(r.inputInfo) > 0 {
+		if extra == nil {
+			extra = gin.H{}
+		}
+		extra["input_image_info"] = r.inputInfo
+	}
+	writeImageResult(c, r.Model, result, r.ResponseFormat, r.output, extra)
+}
+
+// writeImageResult 写出图片生成结果：二进制格式通过响应头报告数量、尺寸、种子和多图信息，
+// 其他格式返回 JSON，extra 中的字段原样合并到响应中
+func writeImageResult(c *gin.Context, model string, result *controllers.ImageResult, format string, output *imageOutput, extra gin.H) {
+	if defaultResponseFormat(format) == responseFormatBinary {
+		setImageCountHeaders(c, result)
+		setImageSizeHeader(c, result)
+		setImageSeedHeader(c, result)
+		setMultiImageHeader(c, result)
+		writeBinaryImages(c, result, output)
+		return
+	}
+	data, err := formatImageResponse(c, model, result, format, output)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	resp := gin.H{"created": utils.UnixTimestamp(), "data": data}
+	for key, value := range extra {
+		resp[key] = value
+	}
+	applyImageCountReport(resp, result)
+	applyImageSizeReport(resp, result)
+	applyImageSeedReport(resp, result)
+	applyMultiImageReport(resp, result)
+	c.PureJSON(http.StatusOK, resp)
+}
@@ -0,0 +1,89 @@
+package routes
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gloryhry/jimeng-api-go/internal/api/controllers"
+)
+
+// maxSeed 上游接受的种子上限
+const maxSeed = 4294967295
+
+// parseSeed 校验 seed，未指定时返回 0 表示随机
+func parseSeed(seed *int64) (int64, error) {
+	if seed == nil {
+		return 0, nil
+	}
+	if *seed < 1 || *seed > maxSeed {
+		return 0, fmt.Errorf("seed 必须在 1 到 %d 之间", maxSeed)
+	}
+	return *seed, nil
+}
+
+// parseOptionalInt64 解析可选的 int64 表单字段，空值返回 nil
+func parseOptionalInt64(value string) (*int64, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// seedSequence 指定 seed 时每次提交依次使用 seed、seed+1…，按 n 多次提交时结果不同但可复现；未指定时始终返回 0（随机）
+func seedSequence(seed int64) func() int64 {
+	var mu sync.Mutex
+	next := seed
+	return func() int64 {
+		if seed == 0 {
+			return 0
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		current := next
+		if next++; next > maxSeed {
+			next = 1
+		}
+		return current
+	}
+}
+
+// imageSeeds 返回与结果图片一一对应的种子
+func imageSeeds(result *controllers.ImageResult) []int64 {
+	if len(result.Seeds) == len(result.URLs) {
+		return result.Seeds
+	}
+	seeds := make([]int64, len(result.URLs))
+	for i := range seeds {
+		seeds[i] = result.Seed
+	}
+	return seeds
+}
+
+// applyImageSeedReport 在响应中返回每张图片使用的种子，顺序与 data 一致
+func applyImageSeedReport(resp gin.H, result *controllers.ImageResult) {
+	if result.Seed == 0 {
+		return
+	}
+	resp["seed"] = result.Seed
+	resp["seeds"] = imageSeeds(result)
+}
+
+// setImageSeedHeader 二进制响应通过响应头返回种子，多张图片时按顺序以逗号分隔
+func setImageSeedHeader(c *gin.Context, result *controllers.ImageResult) {
+	if result.Seed == 0 {
+		return
+	}
+	seeds := imageSeeds(result)
+	values := make([]string, len(seeds))
+	for i, seed := range seeds {
+		values[i] = strconv.FormatInt(seed, 10)
+	}
+	c.Header("X-Image-Seeds", strings.Join(values, ","))
+}
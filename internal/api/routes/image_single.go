@@ -0,0 +1,83 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// singleImageRequest 单图接口（变体、扩图、超分）共用的请求参数
+// 各接口的请求体嵌入该结构体，再追加自己的字段
+type singleImageRequest struct {
+	imageRequest
+	Image  interface{} `json:"image"`
+	Prompt string      `json:"prompt"`
+
+	// 由 bindSingleImageRequest 填充：上传文件、字符串输入或 HistoryImageRef，未提供图片时为 nil
+	image interface{}
+}
+
+// bindSingleImageRequest 解析 multipart 或 JSON 请求体并校验公共参数，失败时已写出响应并返回 false
+// body 是嵌入了 req 的完整请求体，用于 JSON 绑定接口自己的字段；parseForm 读取 multipart 中接口自己的字段，可为 nil
+// requireImage 为 false 时允许不提供图片（超分可改用 history_id）
+func bindSingleImageRequest(c *gin.Context, body interface{}, req *singleImageRequest, requireImage bool, parseForm func() error) bool {
+	if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		if err := bindSingleImageForm(c, req, requireImage); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return false
+		}
+		if parseForm != nil {
+			if err := parseForm(); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return false
+			}
+		}
+	} else {
+		if err := c.ShouldBindJSON(body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return false
+		}
+		if req.Image == nil && requireImage {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "缺少图片"})
+			return false
+		}
+		if req.Image != nil {
+			images, err := parseImageInputs("image", []interface{}{req.Image})
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return false
+			}
+			req.image = images[0]
+		}
+	}
+	if err := req.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// bindSingleImageForm 读取 multipart 中的单张 image 文件和公共字段
+func bindSingleImageForm(c *gin.Context, req *singleImageRequest, requireImage bool) error {
+	if err := c.Request.ParseMultipartForm(32 << 20); err != nil {
+		return fmt.Errorf("上传图片失败")
+	}
+	files := c.Request.MultipartForm.File["image"]
+	switch {
+	case requireImage && len(files) != 1:
+		return fmt.Errorf("需要且只能提供1张图片")
+	case len(files) > 1:
+		return fmt.Errorf("只能提供1张图片")
+	case len(files) == 1:
+		info, err := inspectImageFile(files[0])
+		if err != nil {
+			return err
+		}
+		req.image = files[0]
+		req.inputInfo = append(req.inputInfo, info)
+	}
+	req.Prompt = c.PostForm("prompt")
+	return req.readForm(c)
+}
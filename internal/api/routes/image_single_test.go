@@ -0,0 +1,61 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/config"
+)
+
+func TestBindSingleImageRequest(t *testing.T) {
+	previous := config.System
+	t.Cleanup(func() { config.System = previous })
+	config.System = &config.SystemConfig{ImageMaxCount: 4}
+
+	tests := []struct {
+		name         string
+		body         string
+		requireImage bool
+		wantOK       bool
+		wantImage    bool
+	}{
+		{name: "image url", body: `{"image":"https://example.com/a.png","n":2,"seed":7}`, requireImage: true, wantOK: true, wantImage: true},
+		{name: "missing image", body: `{"prompt":"x"}`, requireImage: true},
+		{name: "image optional", body: `{"history_id":"h1"}`, wantOK: true},
+		{name: "count over limit", body: `{"image":"https://example.com/a.png","n":5}`, requireImage: true},
+		{name: "bad output format", body: `{"image":"https://example.com/a.png","output_format":"gif"}`, requireImage: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			var body struct {
+				singleImageRequest
+				HistoryID string `json:"history_id"`
+			}
+			req := &body.singleImageRequest
+			ok := bindSingleImageRequest(c, &body, req, tt.requireImage, nil)
+			if ok != tt.wantOK {
+				t.Fatalf("bindSingleImageRequest() = %v, want %v (status %d, body %s)", ok, tt.wantOK, recorder.Code, recorder.Body.String())
+			}
+			if !ok {
+				if recorder.Code != http.StatusBadRequest {
+					t.Errorf("status = %d, want 400", recorder.Code)
+				}
+				return
+			}
+			if (req.image != nil) != tt.wantImage {
+				t.Errorf("image = %v, want present %v", req.image, tt.wantImage)
+			}
+			if tt.wantImage && (req.count != 2 || req.seed != 7) {
+				t.Errorf("count, seed = %d, %d, want 2, 7", req.count, req.seed)
+			}
+		})
+	}
+}
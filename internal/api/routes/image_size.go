@@ -0,0 +1,77 @@
+package routes
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gloryhry/jimeng-api-go/internal/api/builders"
+	"github.com/gloryhry/jimeng-api-go/internal/api/controllers"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/config"
+)
+
+// resolveImageDimensions 合并 size、quality、ratio、resolution:
+// 显式的 ratio、resolution 优先，其次由 quality 决定分辨率，size 决定比例及未确定的分辨率
+func resolveImageDimensions(size, quality, ratio, resolution string) (string, string, error) {
+	if resolution == "" {
+		qualityResolution, err := qualityToResolution(quality)
+		if err != nil {
+			return "", "", err
+		}
+		resolution = qualityResolution
+	}
+	return applyImageSize(size, ratio, resolution)
+}
+
+// qualityToResolution 按 imageQuality 配置将 OpenAI quality 映射为分辨率，未传时返回空，未知取值返回错误
+func qualityToResolution(quality string) (string, error) {
+	quality = strings.ToLower(strings.TrimSpace(quality))
+	if quality == "" {
+		return "", nil
+	}
+	resolution, ok := config.System.ImageQuality[quality]
+	if !ok {
+		supported := make([]string, 0, len(config.System.ImageQuality))
+		for key := range config.System.ImageQuality {
+			supported = append(supported, key)
+		}
+		sort.Strings(supported)
+		return "", fmt.Errorf("不支持的 quality \"%s\"，可选 %s", quality, strings.Join(supported, "/"))
+	}
+	return resolution, nil
+}
+
+// applyImageSize 将 OpenAI 风格的 size 映射为最接近的比例和分辨率，只填充未指定的 ratio、resolution
+func applyImageSize(size, ratio, resolution string) (string, string, error) {
+	size = strings.TrimSpace(size)
+	if size == "" || strings.EqualFold(size, "auto") {
+		return ratio, resolution, nil
+	}
+	width, height, err := builders.ParseSize(size)
+	if err != nil {
+		return "", "", err
+	}
+	match := builders.NearestSize(width, height)
+	if ratio == "" {
+		ratio = match.Ratio
+	}
+	if resolution == "" {
+		resolution = match.Resolution
+	}
+	return ratio, resolution, nil
+}
+
+// applyImageSizeReport 在响应中报告实际输出尺寸
+func applyImageSizeReport(resp gin.H, result *controllers.ImageResult) {
+	if result.Width > 0 && result.Height > 0 {
+		resp["size"] = fmt.Sprintf("%dx%d", result.Width, result.Height)
+	}
+}
+
+// setImageSizeHeader 二进制响应通过响应头报告实际输出尺寸
+func setImageSizeHeader(c *gin.Context, result *controllers.ImageResult) {
+	if result.Width > 0 && result.Height > 0 {
+		c.Header("X-Image-Size", fmt.Sprintf("%dx%d", result.Width, result.Height))
+	}
+}
@@ -0,0 +1,94 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gloryhry/jimeng-api-go/internal/api/controllers"
+)
+
+// handleImageUpscale 超分接口: 放大单张图片，或通过 history_id + item_index 引用已生成的结果（无需重新上传）
+func handleImageUpscale(c *gin.Context) {
+	tokens, err := pickTokens(c)
+	if err != nil {
+		return
+	}
+	var reqBody struct {
+		singleImageRequest
+		HistoryID string `json:"history_id"`
+		ItemIndex *int   `json:"item_index"`
+	}
+	req := &reqBody.singleImageRequest
+	parseForm := func() (err error) {
+		reqBody.HistoryID = c.PostForm("history_id")
+		if reqBody.ItemIndex, err = parseOptionalInt(c.PostForm("item_index")); err != nil {
+			return fmt.Errorf("item_index 必须是整数")
+		}
+		return nil
+	}
+	if !bindSingleImageRequest(c, &reqBody, req, false, parseForm) {
+		return
+	}
+	image := req.image
+	if ref, ok := image.(controllers.HistoryImageRef); ok {
+		image = nil
+		reqBody.HistoryID, reqBody.ItemIndex = ref.HistoryID, &ref.ItemIndex
+	}
+	if (image == nil) == (reqBody.HistoryID == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "需要提供 image 或 history_id 之一"})
+		return
+	}
+	// 超分只放大一张图片，目标尺寸由原图和 resolution/quality 决定，不接受生成数量、尺寸比例和提示词
+	for _, field := range []struct {
+		name string
+		set  bool
+	}{
+		{"n", req.N != nil},
+		{"size", req.Size != ""},
+		{"ratio", req.Ratio != ""},
+		{"prompt", req.Prompt != ""},
+	} {
+		if field.set {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("超分接口不支持 %s 参数", field.name)})
+			return
+		}
+	}
+
+	var source *controllers.UpscaleSource
+	if reqBody.HistoryID != "" {
+		ref := controllers.HistoryImageRef{HistoryID: reqBody.HistoryID}
+		if reqBody.ItemIndex != nil {
+			ref.ItemIndex = *reqBody.ItemIndex
+		}
+		historyImage, token, err := findHistoryImage(tokens, ref)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		source = &controllers.UpscaleSource{URI: historyImage.URI, Width: historyImage.Width, Height: historyImage.Height}
+		tokens = []string{token}
+	} else if source, err = controllers.PrepareUpscale(image); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	options := &controllers.ImageOptions{
+		Resolution:     req.resolution,
+		SampleStrength: req.SampleStrength,
+		Seed:           req.seed,
+	}
+	entry := newAuditEntry(c, tokens[0], req.Model, "", "")
+	if image != nil {
+		entry.InputImageHashes = hashImageInputs([]interface{}{source.Image})
+	} else {
+		entry.InputImageHashes = hashImageInputs([]interface{}{source.URI})
+	}
+	result, err := controllers.GenerateImageUpscale(req.Model, source, options, tokens[0])
+	finishAudit(entry, imageHistoryID(result), imageURLs(result), err)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	req.writeResult(c, result, nil)
+}
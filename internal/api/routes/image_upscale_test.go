@@ -0,0 +1,41 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/config"
+)
+
+func TestUpscaleRejectsUnsupportedFields(t *testing.T) {
+	previous := config.System
+	t.Cleanup(func() { config.System = previous })
+	config.System = &config.SystemConfig{ImageMaxCount: 4}
+
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+	}{
+		{name: "n", body: `{"history_id":"h1","n":2}`, wantErr: "超分接口不支持 n 参数"},
+		{name: "size", body: `{"history_id":"h1","size":"1024x1024"}`, wantErr: "超分接口不支持 size 参数"},
+		{name: "ratio", body: `{"history_id":"h1","ratio":"16:9"}`, wantErr: "超分接口不支持 ratio 参数"},
+		{name: "prompt", body: `{"history_id":"h1","prompt":"more detail"}`, wantErr: "超分接口不支持 prompt 参数"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Request = httptest.NewRequest(http.MethodPost, "/v1/images/upscale", strings.NewReader(tt.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Request.Header.Set("Authorization", "Bearer token")
+			handleImageUpscale(c)
+			if recorder.Code != http.StatusBadRequest || !strings.Contains(recorder.Body.String(), tt.wantErr) {
+				t.Errorf("response = %d %s, want 400 %q", recorder.Code, recorder.Body.String(), tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/gloryhry/jimeng-api-go/internal/api/controllers"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/config"
+)
+
+// handleImageVariations OpenAI 兼容的图片变体接口: 单张图片、无提示词，基于图生图流程生成
+func handleImageVariations(c *gin.Context) {
+	tokens, err := pickTokens(c)
+	if err != nil {
+		return
+	}
+	req := &singleImageRequest{}
+	if !bindSingleImageRequest(c, req, req, true, nil) {
+		return
+	}
+	images := []interface{}{req.image}
+	if tokens, err = pinHistoryTokens(tokens, images); err != nil {
+		respondError(c, err)
+		return
+	}
+	seeds := seedSequence(req.seed)
+	options := &controllers.ImageOptions{
+		Ratio:          req.ratio,
+		Resolution:     req.resolution,
+		SampleStrength: req.SampleStrength,
+	}
+	entry := newAuditEntry(c, tokens[0], req.Model, config.System.Variation.Prompt, "")
+	entry.InputImageHashes = hashImageInputs(images)
+	uploads := newTokenUploads(images)
+	result, err := generateImageCount(req.count, controllers.ImagesPerComposition, tokens, func(token string) (*controllers.ImageResult, error) {
+		uploaded, err := uploads.get(token)
+		if err != nil {
+			return nil, err
+		}
+		opts := *options
+		opts.Seed = seeds()
+		return controllers.GenerateImageVariations(req.Model, uploaded[0], &opts, token)
+	})
+	finishAudit(entry, imageHistoryID(result), imageURLs(result), err)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	req.writeResult(c, result, nil)
+}
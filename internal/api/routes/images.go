@@ -2,16 +2,15 @@ package routes
 
 import (
 	"fmt"
-	"io"
 	"mime/multipart"
 	"net/http"
 	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gloryhry/jimeng-api-go/internal/api/builders"
 	"github.com/gloryhry/jimeng-api-go/internal/api/controllers"
-	apiErrors "github.com/gloryhry/jimeng-api-go/internal/pkg/errors"
-	"github.com/gloryhry/jimeng-api-go/internal/pkg/logger"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/storage"
 	"github.com/gloryhry/jimeng-api-go/internal/pkg/utils"
 )
 
@@ -21,64 +20,78 @@ func RegisterImageRoutes(v1 *gin.RouterGroup) {
 	group.POST("/generations", handleImageGenerations)
 	group.POST("/compositions", handleImageCompositions)
 	group.POST("/edits", handleImageEdits)
+	group.POST("/variations", handleImageVariations)
+	group.POST("/outpaint", handleImageOutpaint)
+	group.POST("/upscale", handleImageUpscale)
 }
 
 func handleImageGenerations(c *gin.Context) {
 	var req struct {
-		Model            string  `json:"model"`
-		Prompt           string  `json:"prompt" binding:"required"`
-		Ratio            string  `json:"ratio"`
-		Resolution       string  `json:"resolution"`
-		IntelligentRatio bool    `json:"intelligent_ratio"`
-		SampleStrength   float64 `json:"sample_strength"`
-		NegativePrompt   string  `json:"negative_prompt"`
-		ResponseFormat   string  `json:"response_format"`
-		N                *int    `json:"n"`
+		imageRequest
+		Prompt           string `json:"prompt" binding:"required"`
+		IntelligentRatio bool   `json:"intelligent_ratio"`
+		NegativePrompt   string `json:"negative_prompt"`
+		MultiImage       *bool  `json:"multi_image"`
+		Count            *int   `json:"count"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	token, err := pickToken(c)
+	if err := req.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	seeds := seedSequence(req.seed)
+	multiImageCount, err := parseMultiImageCount(req.MultiImage, req.Count)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	tokens, err := pickTokens(c)
 	if err != nil {
 		return
 	}
 	options := &controllers.ImageOptions{
-		Ratio:            req.Ratio,
-		Resolution:       req.Resolution,
+		Ratio:            req.ratio,
+		Resolution:       req.resolution,
 		SampleStrength:   req.SampleStrength,
 		NegativePrompt:   req.NegativePrompt,
 		IntelligentRatio: req.IntelligentRatio,
+		MultiImage:       req.MultiImage,
+		Count:            multiImageCount,
 	}
-	urls, err := controllers.GenerateImages(req.Model, req.Prompt, options, token)
-	if err != nil {
-		respondError(c, err)
-		return
+	perSubmission := controllers.ImagesPerGeneration
+	if multiImageCount > 0 {
+		perSubmission = multiImageCount
 	}
-	data, err := formatImageResponse(urls, req.ResponseFormat, req.N)
+	entry := newAuditEntry(c, tokens[0], req.Model, req.Prompt, req.NegativePrompt)
+	result, err := generateImageCount(req.count, perSubmission, tokens, func(token string) (*controllers.ImageResult, error) {
+		opts := *options
+		opts.Seed = seeds()
+		return controllers.GenerateImages(req.Model, req.Prompt, &opts, token)
+	})
+	finishAudit(entry, imageHistoryID(result), imageURLs(result), err)
 	if err != nil {
 		respondError(c, err)
 		return
 	}
-	c.PureJSON(http.StatusOK, gin.H{"created": utils.UnixTimestamp(), "data": data})
+	req.writeResult(c, result, nil)
 }
 
 func handleImageCompositions(c *gin.Context) {
-	token, err := pickToken(c)
+	tokens, err := pickTokens(c)
 	if err != nil {
 		return
 	}
 	isMultipart := strings.HasPrefix(c.ContentType(), "multipart/form-data")
 	var images []interface{}
+	var references []builders.BlendReference
 	var reqBody struct {
-		Model            string        `json:"model"`
+		imageRequest
 		Prompt           string        `json:"prompt" binding:"required"`
 		NegativePrompt   string        `json:"negative_prompt"`
-		Ratio            string        `json:"ratio"`
-		Resolution       string        `json:"resolution"`
-		SampleStrength   float64       `json:"sample_strength"`
 		IntelligentRatio bool          `json:"intelligent_ratio"`
-		ResponseFormat   string        `json:"response_format"`
 		Images           []interface{} `json:"images"`
 	}
 	if isMultipart {
@@ -92,21 +105,25 @@ func handleImageCompositions(c *gin.Context) {
 			return
 		}
 		for _, fh := range files {
-			buf, err := readFileBytes(fh)
+			info, err := inspectImageFile(fh)
 			if err != nil {
 				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 				return
 			}
-			images = append(images, buf)
+			images = append(images, fh)
+			reqBody.inputInfo = append(reqBody.inputInfo, info)
 		}
-		reqBody.Model = c.PostForm("model")
 		reqBody.Prompt = c.PostForm("prompt")
 		reqBody.NegativePrompt = c.PostForm("negative_prompt")
-		reqBody.Ratio = c.PostForm("ratio")
-		reqBody.Resolution = c.PostForm("resolution")
-		reqBody.ResponseFormat = c.PostForm("response_format")
-		reqBody.SampleStrength = parseFloat(c.PostForm("sample_strength"))
 		reqBody.IntelligentRatio = parseBool(c.PostForm("intelligent_ratio"))
+		if err := reqBody.readForm(c); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if references, err = parseBlendReferenceForm(c, len(images)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 	} else {
 		if err := c.ShouldBindJSON(&reqBody); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -116,47 +133,63 @@ func handleImageCompositions(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "至少提供1张图片"})
 			return
 		}
-		images = reqBody.Images
+		if images, references, err = parseBlendImages(reqBody.Images); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if tokens, err = pinHistoryTokens(tokens, images); err != nil {
+			respondError(c, err)
+			return
+		}
 	}
+	if err := reqBody.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	seeds := seedSequence(reqBody.seed)
 	options := &controllers.ImageOptions{
-		Ratio:            reqBody.Ratio,
-		Resolution:       reqBody.Resolution,
+		Ratio:            reqBody.ratio,
+		Resolution:       reqBody.resolution,
 		SampleStrength:   reqBody.SampleStrength,
 		NegativePrompt:   reqBody.NegativePrompt,
 		IntelligentRatio: reqBody.IntelligentRatio,
+		References:       references,
 	}
-	urls, err := controllers.GenerateImageComposition(reqBody.Model, reqBody.Prompt, images, options, token)
-	if err != nil {
-		respondError(c, err)
-		return
-	}
-	data, err := formatImageResponse(urls, reqBody.ResponseFormat, nil)
+	entry := newAuditEntry(c, tokens[0], reqBody.Model, reqBody.Prompt, reqBody.NegativePrompt)
+	entry.InputImageHashes = hashImageInputs(images)
+	uploads := newTokenUploads(images)
+	result, err := generateImageCount(reqBody.count, controllers.ImagesPerComposition, tokens, func(token string) (*controllers.ImageResult, error) {
+		uploaded, err := uploads.get(token)
+		if err != nil {
+			return nil, err
+		}
+		opts := *options
+		opts.Seed = seeds()
+		return controllers.GenerateImageComposition(reqBody.Model, reqBody.Prompt, uploaded, &opts, token)
+	})
+	finishAudit(entry, imageHistoryID(result), imageURLs(result), err)
 	if err != nil {
 		respondError(c, err)
 		return
 	}
-	c.PureJSON(http.StatusOK, gin.H{"created": utils.UnixTimestamp(), "data": data, "input_images": len(images)})
+	reqBody.writeResult(c, result, gin.H{"input_images": len(images)})
 }
 
 func handleImageEdits(c *gin.Context) {
-	token, err := pickToken(c)
+	tokens, err := pickTokens(c)
 	if err != nil {
 		return
 	}
 	isMultipart := strings.HasPrefix(c.ContentType(), "multipart/form-data")
 	var images []interface{}
 	var reqBody struct {
-		Model          string        `json:"model"`
+		imageRequest
 		Prompt         interface{}   `json:"prompt"`
-		Size           string        `json:"size"`
-		Quality        string        `json:"quality"`
-		Ratio          string        `json:"ratio"`
-		Resolution     string        `json:"resolution"`
 		NegativePrompt string        `json:"negative_prompt"`
-		SampleStrength float64       `json:"sample_strength"`
-		ResponseFormat string        `json:"response_format"`
 		Images         []interface{} `json:"images"`
+		Mask           interface{}   `json:"mask"`
 	}
+	var mask interface{}
 	if isMultipart {
 		if err := c.Request.ParseMultipartForm(32 << 20); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "上传图片失败"})
@@ -171,38 +204,61 @@ func handleImageEdits(c *gin.Context) {
 			return
 		}
 		for _, fh := range files {
-			buf, err := readFileBytes(fh)
+			info, err := inspectImageFile(fh)
 			if err != nil {
 				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 				return
 			}
-			images = append(images, buf)
+			images = append(images, fh)
+			reqBody.inputInfo = append(reqBody.inputInfo, info)
+		}
+		if masks := c.Request.MultipartForm.File["mask"]; len(masks) > 0 {
+			mask = masks[0]
 		}
-		reqBody.Model = c.PostForm("model")
 		reqBody.Prompt = c.PostForm("prompt")
-		reqBody.Size = c.PostForm("size")
-		reqBody.Quality = c.PostForm("quality")
-		reqBody.Ratio = c.PostForm("ratio")
-		reqBody.Resolution = c.PostForm("resolution")
-		reqBody.ResponseFormat = c.PostForm("response_format")
-		reqBody.SampleStrength = parseFloat(c.PostForm("sample_strength"))
 		reqBody.NegativePrompt = c.PostForm("negative_prompt")
+		if err := reqBody.readForm(c); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 	} else {
 		if err := c.ShouldBindJSON(&reqBody); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		images = reqBody.Images
+		if images, err = parseImageInputs("images", reqBody.Images); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if tokens, err = pinHistoryTokens(tokens, images); err != nil {
+			respondError(c, err)
+			return
+		}
+		if reqBody.Mask != nil {
+			masks, err := parseImageInputs("mask", []interface{}{reqBody.Mask})
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			mask = masks[0]
+		}
 	}
 	if len(images) == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "至少提供1张图片"})
 		return
 	}
+	if mask != nil && len(images) > 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "使用 mask 时只能提供1张图片"})
+		return
+	}
+	if err := reqBody.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	seeds := seedSequence(reqBody.seed)
 	mapped := mapOpenAIParams(struct {
 		Model          string
 		Prompt         interface{}
-		Size           string
-		Quality        string
 		Ratio          string
 		Resolution     string
 		NegativePrompt string
@@ -212,38 +268,60 @@ func handleImageEdits(c *gin.Context) {
 	}{
 		Model:          reqBody.Model,
 		Prompt:         reqBody.Prompt,
-		Size:           reqBody.Size,
-		Quality:        reqBody.Quality,
-		Ratio:          reqBody.Ratio,
-		Resolution:     reqBody.Resolution,
+		Ratio:          reqBody.ratio,
+		Resolution:     reqBody.resolution,
 		NegativePrompt: reqBody.NegativePrompt,
 		SampleStrength: reqBody.SampleStrength,
 		ResponseFormat: reqBody.ResponseFormat,
 		Images:         reqBody.Images,
 	})
-	urls, err := controllers.GenerateImageEdits(mapped.Model, mapped.Prompt, images, &controllers.ImageOptions{
-		Ratio:          mapped.Ratio,
-		Resolution:     mapped.Resolution,
-		SampleStrength: mapped.SampleStrength,
-		NegativePrompt: reqBody.NegativePrompt,
-	}, token)
-	if err != nil {
-		respondError(c, err)
-		return
+	entry := newAuditEntry(c, tokens[0], mapped.Model, mapped.Prompt, reqBody.NegativePrompt)
+	entry.InputImageHashes = hashImageInputs(images)
+	// 提供 mask 或单张原图是带透明通道的 PNG 时走局部重绘，只重绘遮罩区域；其他输入不读取内容，直接按普通编辑流式上传
+	var inpaint *controllers.InpaintSource
+	if len(images) == 1 && (mask != nil || controllers.HasAlphaChannel(images[0])) {
+		source, err := historyImageURL(tokens, images[0])
+		if err == nil {
+			inpaint, err = controllers.PrepareInpaint(source, mask)
+		}
+		if err != nil {
+			finishAudit(entry, "", nil, err)
+			respondError(c, err)
+			return
+		}
+		if inpaint.Mask == nil {
+			inpaint = nil
+		}
 	}
-	data, err := formatImageResponse(urls, mapped.ResponseFormat, mapped.Count)
+	uploads := newTokenUploads(images)
+	result, err := generateImageCount(reqBody.count, controllers.ImagesPerComposition, tokens, func(token string) (*controllers.ImageResult, error) {
+		opts := &controllers.ImageOptions{
+			Ratio:          mapped.Ratio,
+			Resolution:     mapped.Resolution,
+			SampleStrength: mapped.SampleStrength,
+			NegativePrompt: reqBody.NegativePrompt,
+			Seed:           seeds(),
+		}
+		if inpaint != nil {
+			return controllers.GenerateImageInpaint(mapped.Model, mapped.Prompt, inpaint, opts, token)
+		}
+		uploaded, err := uploads.get(token)
+		if err != nil {
+			return nil, err
+		}
+		return controllers.GenerateImageEdits(mapped.Model, mapped.Prompt, uploaded, opts, token)
+	})
+	finishAudit(entry, imageHistoryID(result), imageURLs(result), err)
 	if err != nil {
 		respondError(c, err)
 		return
 	}
-	c.PureJSON(http.StatusOK, gin.H{"created": utils.UnixTimestamp(), "data": data})
+	reqBody.writeResult(c, result, nil)
 }
 
 func mapOpenAIParams(body struct {
 	Model          string
 	Prompt         interface{}
-	Size           string
-	Quality        string
 	Ratio          string
 	Resolution     string
 	NegativePrompt string
@@ -257,20 +335,11 @@ func mapOpenAIParams(body struct {
 	Resolution     string
 	SampleStrength float64
 	ResponseFormat string
-	Count          *int
 } {
 	prompt := normalizePrompt(body.Prompt)
 	if body.NegativePrompt != "" {
 		prompt = fmt.Sprintf("%s negative_prompt: %s", prompt, body.NegativePrompt)
 	}
-	ratio := body.Ratio
-	if ratio == "" {
-		ratio = mapSizeToRatio(body.Size)
-	}
-	resolution := body.Resolution
-	if resolution == "" {
-		resolution = mapQualityToResolution(body.Quality)
-	}
 	return struct {
 		Model          string
 		Prompt         string
@@ -278,56 +347,75 @@ func mapOpenAIParams(body struct {
 		Resolution     string
 		SampleStrength float64
 		ResponseFormat string
-		Count          *int
 	}{
 		Model:          body.Model,
 		Prompt:         prompt,
-		Ratio:          ratio,
-		Resolution:     resolution,
+		Ratio:          body.Ratio,
+		Resolution:     body.Resolution,
 		SampleStrength: body.SampleStrength,
 		ResponseFormat: defaultResponseFormat(body.ResponseFormat),
 	}
 }
 
-func formatImageResponse(urls []string, format string, limit *int) ([]map[string]string, error) {
-	if limit != nil && *limit > 0 && *limit < len(urls) {
-		urls = urls[:*limit]
-	}
+func formatImageResponse(c *gin.Context, model string, result *controllers.ImageResult, format string, output *imageOutput) ([]map[string]string, error) {
+	urls := result.URLs
+	meta := storage.MirrorMeta{Kind: "images", Model: model, TaskID: result.HistoryID}
 	format = defaultResponseFormat(format)
+	if format == "b64_json" || (output != nil && output.Format != "") {
+		return downloadImageResults(c, meta, urls, format, output)
+	}
+	localURLs := mirrorResults(c, meta, urls)
 	data := make([]map[string]string, 0, len(urls))
-	if format == "b64_json" {
-		for i, url := range urls {
-			b64, err := utils.FetchFileBASE64(url)
-			if err != nil {
-				logger.Error(fmt.Sprintf("下载图片转BASE64失败 (第%d张): %v", i+1, err))
-				return nil, apiErrors.ErrAPIRequestFailed(
-					fmt.Sprintf("下载图片转BASE64失败: %v", err),
-				).SetHTTPStatusCode(502)
-			}
-			data = append(data, map[string]string{"b64_json": b64})
-		}
-	} else {
-		for _, url := range urls {
-			data = append(data, map[string]string{"url": url})
-		}
+	for _, url := range urls {
+		data = append(data, map[string]string{"url": url})
+	}
+	for i, localURL := range localURLs {
+		applyLocalURL(data[i], localURL)
 	}
 	return data, nil
 }
 
+func imageHistoryID(result *controllers.ImageResult) string {
+	if result == nil {
+		return ""
+	}
+	if len(result.HistoryIDs) > 1 {
+		return strings.Join(result.HistoryIDs, ",")
+	}
+	return result.HistoryID
+}
+
+func imageURLs(result *controllers.ImageResult) []string {
+	if result == nil {
+		return nil
+	}
+	return result.URLs
+}
+
 func defaultResponseFormat(v string) string {
-	if v == "b64_json" {
+	if v == "b64_json" || v == responseFormatBinary {
 		return v
 	}
 	return "url"
 }
 
-func readFileBytes(fh *multipart.FileHeader) ([]byte, error) {
+// inspectImageFile 只读取上传文件头部按魔数校验图片格式并解析尺寸，文件内容在上传时流式读取
+func inspectImageFile(fh *multipart.FileHeader) (gin.H, error) {
 	file, err := fh.Open()
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("读取文件 %s 失败: %v", fh.Filename, err)
 	}
 	defer file.Close()
-	return io.ReadAll(file)
+	info, err := utils.DetectImageInfoReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("文件 %s 不是有效的图片: %v", fh.Filename, err)
+	}
+	return gin.H{
+		"filename": fh.Filename,
+		"format":   info.Format,
+		"width":    info.Width,
+		"height":   info.Height,
+	}, nil
 }
 
 func parseFloat(value string) float64 {
@@ -343,32 +431,6 @@ func parseBool(value string) bool {
 	return value == "true" || value == "1"
 }
 
-func mapSizeToRatio(size string) string {
-	switch size {
-	case "1024x1024":
-		return "1:1"
-	case "1536x1024":
-		return "3:2"
-	case "1024x1536":
-		return "2:3"
-	case "auto":
-		return "16:9"
-	default:
-		return "1:1"
-	}
-}
-
-func mapQualityToResolution(quality string) string {
-	switch strings.ToLower(quality) {
-	case "high":
-		return "1k"
-	case "low":
-		return "4k"
-	default:
-		return "2k"
-	}
-}
-
 func normalizePrompt(prompt interface{}) string {
 	switch value := prompt.(type) {
 	case string:
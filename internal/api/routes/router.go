@@ -32,7 +32,9 @@ func RegisterRoutes(engine *gin.Engine) {
 	RegisterChatRoutes(v1)
 	RegisterVideoRoutes(v1)
 	RegisterModelRoutes(v1)
+	RegisterFileSignRoutes(v1)
 
 	// 非 V1 路由
 	RegisterTokenRoutes(engine.Group(""))
+	RegisterFileRoutes(engine.Group(""))
 }
@@ -0,0 +1,206 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gloryhry/jimeng-api-go/internal/api/controllers"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/config"
+	apiErrors "github.com/gloryhry/jimeng-api-go/internal/pkg/errors"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/logger"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/storage"
+)
+
+const videoUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36"
+
+// 代理视频时透传的上游响应头
+var videoProxyHeaders = []string{"Content-Type", "Content-Length", "Content-Range", "Accept-Ranges", "ETag", "Last-Modified"}
+
+var videoClient = &http.Client{}
+
+func handleVideoContent(c *gin.Context) {
+	token, err := pickToken(c)
+	if err != nil {
+		return
+	}
+	videoURL, err := controllers.GetVideoURL(c.Param("id"), token)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	proxyVideo(c, videoURL)
+}
+
+// proxyVideo 代理上游视频，透传 Range/If-Range 请求头和分段响应
+func proxyVideo(c *gin.Context, videoURL string) {
+	req, err := http.NewRequestWithContext(c.Request.Context(), "GET", videoURL, nil)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	req.Header.Set("User-Agent", videoUserAgent)
+	for _, name := range []string{"Range", "If-Range"} {
+		if value := c.GetHeader(name); value != "" {
+			req.Header.Set(name, value)
+		}
+	}
+	resp, err := videoClient.Do(req)
+	if err != nil {
+		respondError(c, apiErrors.ErrAPIRequestFailed(fmt.Sprintf("下载视频失败: %v", err)).SetHTTPStatusCode(502))
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 && resp.StatusCode != http.StatusRequestedRangeNotSatisfiable {
+		respondError(c, apiErrors.ErrAPIRequestFailed(fmt.Sprintf("下载视频失败: HTTP %d", resp.StatusCode)).SetHTTPStatusCode(502))
+		return
+	}
+
+	for _, name := range videoProxyHeaders {
+		if value := resp.Header.Get(name); value != "" {
+			c.Header(name, value)
+		}
+	}
+	if resp.Header.Get("Accept-Ranges") == "" {
+		c.Header("Accept-Ranges", "bytes")
+	}
+	c.Status(resp.StatusCode)
+	if _, err := io.Copy(c.Writer, resp.Body); err != nil {
+		logger.Warn(fmt.Sprintf("视频代理传输中断: %v", err))
+	}
+}
+
+// downloadVideoToTemp 将视频下载到临时文件，超过 maxBytes 时返回 413 错误，maxBytes <= 0 表示不限制
+func downloadVideoToTemp(videoURL string, maxBytes int64) (*os.File, error) {
+	req, err := http.NewRequest("GET", videoURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", videoUserAgent)
+	resp, err := videoClient.Do(req)
+	if err != nil {
+		return nil, apiErrors.ErrAPIRequestFailed(fmt.Sprintf("下载视频失败: %v", err)).SetHTTPStatusCode(502)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return nil, apiErrors.ErrAPIRequestFailed(fmt.Sprintf("下载视频失败: HTTP %d", resp.StatusCode)).SetHTTPStatusCode(502)
+	}
+	if maxBytes > 0 && resp.ContentLength > maxBytes {
+		return nil, videoTooLarge(maxBytes)
+	}
+
+	if err := os.MkdirAll(config.System.TmpDirPath(), 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.CreateTemp(config.System.TmpDirPath(), "video-*.mp4")
+	if err != nil {
+		return nil, err
+	}
+	reader := io.Reader(resp.Body)
+	if maxBytes > 0 {
+		reader = io.LimitReader(resp.Body, maxBytes+1)
+	}
+	written, err := io.Copy(file, reader)
+	if err == nil && maxBytes > 0 && written > maxBytes {
+		err = videoTooLarge(maxBytes)
+	}
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, err
+	}
+	return file, nil
+}
+
+// prepareVideoB64 准备 b64_json 响应读取的视频文件并按配置转存，视频只下载一次：
+// 本地存储直接读取转存后的文件，其他情况下载到临时文件后将同一文件写入存储
+func prepareVideoB64(c *gin.Context, meta storage.MirrorMeta, videoURL string) (*os.File, []string, func(), error) {
+	maxBytes := config.System.VideoB64MaxSize << 20
+	_, local := storage.Default().(*storage.LocalStorage)
+	if local {
+		if objects := mirrorObjects(meta, []string{videoURL}); len(objects) > 0 {
+			if maxBytes > 0 && objects[0].Size > maxBytes {
+				return nil, nil, nil, videoTooLarge(maxBytes)
+			}
+			file, err := storage.OpenLocal(objects[0].Key)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			return file, objectURLs(c, objects), func() { file.Close() }, nil
+		}
+	}
+
+	file, err := downloadVideoToTemp(videoURL, maxBytes)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	cleanup := func() {
+		file.Close()
+		os.Remove(file.Name())
+	}
+	// 本地存储转存失败时已回退到下载，不再重复转存
+	if !storage.Enabled() || local {
+		return file, nil, cleanup, nil
+	}
+	obj, err := storage.SaveReader(file, "video/mp4", meta, 0)
+	if _, seekErr := file.Seek(0, io.SeekStart); seekErr != nil {
+		cleanup()
+		return nil, nil, nil, seekErr
+	}
+	if err != nil {
+		logger.Warn(fmt.Sprintf("结果转存失败，将返回上游链接: %v", err))
+		return file, nil, cleanup, nil
+	}
+	return file, objectURLs(c, []*storage.Object{obj}), cleanup, nil
+}
+
+func videoTooLarge(maxBytes int64) error {
+	return apiErrors.ErrAPIRequestParamsInvalid(
+		fmt.Sprintf("视频超过 b64_json 大小上限 %d MB，请使用 url 或 /v1/videos/{id}/content", maxBytes>>20),
+	).SetHTTPStatusCode(http.StatusRequestEntityTooLarge)
+}
+
+// writeVideoB64JSON 边读临时文件边编码 BASE64 写入 JSON 响应，避免整段视频驻留内存
+// 输出结构与普通响应一致: {"created":...,"data":[{"b64_json":"...",<item>}],<extra>}
+func writeVideoB64JSON(c *gin.Context, file *os.File, created int64, item map[string]string, extra gin.H) {
+	itemJSON := marshalPureJSON(item)
+	extraJSON := marshalPureJSON(extra)
+
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Status(http.StatusOK)
+	w := c.Writer
+	io.WriteString(w, `{"created":`+strconv.FormatInt(created, 10)+`,"data":[{"b64_json":"`)
+	encoder := base64.NewEncoder(base64.StdEncoding, w)
+	if _, err := io.Copy(encoder, file); err != nil {
+		logger.Warn(fmt.Sprintf("视频 BASE64 传输中断: %v", err))
+		return
+	}
+	encoder.Close()
+	io.WriteString(w, `"`+jsonFieldsSuffix(itemJSON)+`]`+jsonFieldsSuffix(extraJSON))
+}
+
+// marshalPureJSON 与 c.PureJSON 一致，不转义 HTML 字符
+func marshalPureJSON(value interface{}) []byte {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(value)
+	return bytes.TrimSpace(buf.Bytes())
+}
+
+// jsonFieldsSuffix 将 {"a":1} 转为 ,"a":1}，用于拼接到已写出的对象之后
+func jsonFieldsSuffix(object []byte) string {
+	if len(object) <= 2 {
+		return "}"
+	}
+	return "," + string(object[1:])
+}
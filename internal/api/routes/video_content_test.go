@@ -0,0 +1,135 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/config"
+)
+
+var testVideo = []byte(strings.Repeat("0123456789", 100))
+
+func serveVideo(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/video.mp4" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "video/mp4")
+		http.ServeContent(w, r, "video.mp4", time.Time{}, bytes.NewReader(testVideo))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestProxyVideoRange(t *testing.T) {
+	server := serveVideo(t)
+	tests := []struct {
+		name        string
+		path        string
+		rangeHeader string
+		wantStatus  int
+		wantBody    []byte
+		wantRange   string
+	}{
+		{name: "full content", path: "/video.mp4", wantStatus: http.StatusOK, wantBody: testVideo},
+		{name: "partial content", path: "/video.mp4", rangeHeader: "bytes=10-19", wantStatus: http.StatusPartialContent, wantBody: testVideo[10:20], wantRange: "bytes 10-19/1000"},
+		{name: "open ended range", path: "/video.mp4", rangeHeader: "bytes=990-", wantStatus: http.StatusPartialContent, wantBody: testVideo[990:], wantRange: "bytes 990-999/1000"},
+		{name: "unsatisfiable range", path: "/video.mp4", rangeHeader: "bytes=2000-", wantStatus: http.StatusRequestedRangeNotSatisfiable},
+		{name: "upstream error", path: "/missing.mp4", wantStatus: http.StatusBadGateway},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Request = httptest.NewRequest(http.MethodGet, "/v1/videos/h1/content", nil)
+			if tt.rangeHeader != "" {
+				c.Request.Header.Set("Range", tt.rangeHeader)
+			}
+			proxyVideo(c, server.URL+tt.path)
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if tt.wantBody != nil && !bytes.Equal(recorder.Body.Bytes(), tt.wantBody) {
+				t.Errorf("body = %q, want %q", recorder.Body.Bytes(), tt.wantBody)
+			}
+			if got := recorder.Header().Get("Content-Range"); got != tt.wantRange && tt.wantStatus != http.StatusRequestedRangeNotSatisfiable {
+				t.Errorf("Content-Range = %q, want %q", got, tt.wantRange)
+			}
+			if tt.wantStatus < 400 && recorder.Header().Get("Accept-Ranges") != "bytes" {
+				t.Errorf("Accept-Ranges = %q, want bytes", recorder.Header().Get("Accept-Ranges"))
+			}
+		})
+	}
+}
+
+func TestDownloadVideoToTempLimit(t *testing.T) {
+	previous := config.System
+	t.Cleanup(func() { config.System = previous })
+	config.System = &config.SystemConfig{TmpDir: t.TempDir()}
+	server := serveVideo(t)
+
+	file, err := downloadVideoToTemp(server.URL+"/video.mp4", 0)
+	if err != nil {
+		t.Fatalf("downloadVideoToTemp() error = %v", err)
+	}
+	content, _ := io.ReadAll(file)
+	file.Close()
+	if !bytes.Equal(content, testVideo) {
+		t.Error("downloaded content mismatch")
+	}
+
+	if _, err := downloadVideoToTemp(server.URL+"/video.mp4", 100); err == nil || !strings.Contains(err.Error(), "大小上限") {
+		t.Errorf("downloadVideoToTemp() over limit error = %v", err)
+	}
+}
+
+func TestWriteVideoB64JSON(t *testing.T) {
+	previous := config.System
+	t.Cleanup(func() { config.System = previous })
+	config.System = &config.SystemConfig{TmpDir: t.TempDir()}
+	server := serveVideo(t)
+	file, err := downloadVideoToTemp(server.URL+"/video.mp4", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	writeVideoB64JSON(c, file, 123, map[string]string{"url": "https://example.com/v.mp4?a=1&b=2"}, gin.H{"seed": 7})
+
+	var resp struct {
+		Created int64 `json:"created"`
+		Data    []struct {
+			B64JSON string `json:"b64_json"`
+			URL     string `json:"url"`
+		} `json:"data"`
+		Seed int `json:"seed"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON %q: %v", recorder.Body.String(), err)
+	}
+	if resp.Created != 123 || resp.Seed != 7 || len(resp.Data) != 1 || resp.Data[0].URL != "https://example.com/v.mp4?a=1&b=2" {
+		t.Errorf("response = %+v", resp)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(resp.Data[0].B64JSON)
+	if err != nil || !bytes.Equal(decoded, testVideo) {
+		t.Errorf("b64_json does not decode to the video (err = %v)", err)
+	}
+	if strings.Contains(recorder.Body.String(), `\u0026`) {
+		t.Error("url should not be HTML escaped")
+	}
+}
@@ -1,32 +1,37 @@
 package routes
 
 import (
+	"mime/multipart"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gloryhry/jimeng-api-go/internal/api/controllers"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/storage"
 	"github.com/gloryhry/jimeng-api-go/internal/pkg/utils"
 )
 
 // RegisterVideoRoutes 注册视频接口
 func RegisterVideoRoutes(v1 *gin.RouterGroup) {
 	v1.POST("/video/generations", handleVideoGeneration)
+	v1.GET("/videos/:id/content", handleVideoContent)
 }
 
 func handleVideoGeneration(c *gin.Context) {
 	isMultipart := strings.HasPrefix(c.ContentType(), "multipart/form-data")
 	var req struct {
-		Model          string   `json:"model"`
-		Prompt         string   `json:"prompt" binding:"required"`
-		Ratio          string   `json:"ratio"`
-		Resolution     string   `json:"resolution"`
-		Duration       int      `json:"duration"`
-		FilePaths      []string `json:"file_paths"`
-		FilePathsAlias []string `json:"filePaths"`
-		ResponseFormat string   `json:"response_format"`
-	}
-	var fileBuffers [][]byte
+		Model          string        `json:"model"`
+		Prompt         string        `json:"prompt" binding:"required"`
+		Ratio          string        `json:"ratio"`
+		Resolution     string        `json:"resolution"`
+		Duration       int           `json:"duration"`
+		FilePaths      []interface{} `json:"file_paths"`
+		FilePathsAlias []interface{} `json:"filePaths"`
+		ResponseFormat string        `json:"response_format"`
+		Seed           *int64        `json:"seed"`
+	}
+	var files []*multipart.FileHeader
+	var inputInfo []gin.H
 	if isMultipart {
 		if err := c.Request.ParseMultipartForm(64 << 20); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "上传图片失败"})
@@ -38,17 +43,23 @@ func handleVideoGeneration(c *gin.Context) {
 		req.Resolution = c.PostForm("resolution")
 		req.ResponseFormat = c.PostForm("response_format")
 		req.Duration = int(parseFloat(c.PostForm("duration")))
-		files := c.Request.MultipartForm.File["files"]
-		if len(files) == 0 {
-			files = c.Request.MultipartForm.File["images"]
+		var err error
+		if req.Seed, err = parseOptionalInt64(c.PostForm("seed")); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "seed 必须是整数"})
+			return
 		}
-		for _, fh := range files {
-			buf, err := readFileBytes(fh)
+		uploaded := c.Request.MultipartForm.File["files"]
+		if len(uploaded) == 0 {
+			uploaded = c.Request.MultipartForm.File["images"]
+		}
+		for _, fh := range uploaded {
+			info, err := inspectImageFile(fh)
 			if err != nil {
 				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 				return
 			}
-			fileBuffers = append(fileBuffers, buf)
+			files = append(files, fh)
+			inputInfo = append(inputInfo, info)
 		}
 	} else {
 		if err := c.ShouldBindJSON(&req); err != nil {
@@ -60,38 +71,94 @@ func handleVideoGeneration(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "prompt不能为空"})
 		return
 	}
+	seed, err := parseSeed(req.Seed)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	token, err := pickToken(c)
 	if err != nil {
 		return
 	}
-	paths := req.FilePaths
-	if len(paths) == 0 {
-		paths = req.FilePathsAlias
+	rawPaths, field := req.FilePaths, "file_paths"
+	if len(rawPaths) == 0 {
+		rawPaths, field = req.FilePathsAlias, "filePaths"
+	}
+	paths, err := parseImageInputs(field, rawPaths)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// 引用历史结果作为首尾帧时只能使用生成它的账号
+	tokens, err := pinHistoryTokens(controllers.TokenSplit(c.GetHeader("Authorization")), paths)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if len(tokens) == 1 {
+		token = tokens[0]
 	}
 	options := &controllers.VideoOptions{
-		Ratio:       defaultString(req.Ratio, "1:1"),
-		Resolution:  defaultString(req.Resolution, "720p"),
-		Duration:    req.Duration,
-		FilePaths:   paths,
-		FileBuffers: fileBuffers,
+		Ratio:      defaultString(req.Ratio, "1:1"),
+		Resolution: defaultString(req.Resolution, "720p"),
+		Duration:   req.Duration,
+		Seed:       seed,
+		FilePaths:  paths,
+		Files:      files,
+	}
+	entry := newAuditEntry(c, token, req.Model, req.Prompt, "")
+	entry.InputImageHashes = hashVideoInputs(files, paths)
+	video, err := controllers.GenerateVideo(req.Model, req.Prompt, options, token)
+	if video != nil {
+		var urls []string
+		if video.URL != "" {
+			urls = []string{video.URL}
+		}
+		finishAudit(entry, video.HistoryID, urls, err)
+	} else {
+		finishAudit(entry, "", nil, err)
 	}
-	videoURL, err := controllers.GenerateVideo(req.Model, req.Prompt, options, token)
 	if err != nil {
 		respondError(c, err)
 		return
 	}
-	var data []map[string]string
+	videoURL := video.URL
+	meta := storage.MirrorMeta{Kind: "videos", Model: req.Model, TaskID: video.HistoryID}
 	if defaultResponseFormat(req.ResponseFormat) == "b64_json" {
-		b64, err := utils.FetchFileBASE64(videoURL)
+		file, localURLs, cleanup, err := prepareVideoB64(c, meta, videoURL)
 		if err != nil {
 			respondError(c, err)
 			return
 		}
-		data = []map[string]string{{"b64_json": b64, "revised_prompt": req.Prompt}}
-	} else {
-		data = []map[string]string{{"url": videoURL, "revised_prompt": req.Prompt}}
+		defer cleanup()
+		item := map[string]string{"revised_prompt": req.Prompt}
+		if len(localURLs) > 0 {
+			applyLocalURL(item, localURLs[0])
+		}
+		extra := gin.H{"seed": video.Seed}
+		if len(inputInfo) > 0 {
+			extra["input_image_info"] = inputInfo
+		}
+		writeVideoB64JSON(c, file, utils.UnixTimestamp(), item, extra)
+		return
+	}
+	data := []map[string]string{{"url": videoURL, "revised_prompt": req.Prompt}}
+	for i, localURL := range mirrorResults(c, meta, []string{videoURL}) {
+		applyLocalURL(data[i], localURL)
+	}
+	resp := gin.H{"created": utils.UnixTimestamp(), "data": data, "seed": video.Seed}
+	if len(inputInfo) > 0 {
+		resp["input_image_info"] = inputInfo
+	}
+	c.PureJSON(http.StatusOK, resp)
+}
+
+func hashVideoInputs(files []*multipart.FileHeader, paths []interface{}) []string {
+	inputs := make([]interface{}, 0, len(files)+len(paths))
+	for _, fh := range files {
+		inputs = append(inputs, fh)
 	}
-	c.PureJSON(http.StatusOK, gin.H{"created": utils.UnixTimestamp(), "data": data})
+	return hashImageInputs(append(inputs, paths...))
 }
 
 func defaultString(value, def string) string {
@@ -0,0 +1,108 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// 生成结果
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Entry 审计记录，每条记录序列化为一行 JSON
+type Entry struct {
+	Timestamp        string   `json:"timestamp"`
+	ClientKey        string   `json:"client_key,omitempty"`
+	SourceIP         string   `json:"source_ip"`
+	Endpoint         string   `json:"endpoint"`
+	Model            string   `json:"model,omitempty"`
+	Prompt           string   `json:"prompt,omitempty"`
+	NegativePrompt   string   `json:"negative_prompt,omitempty"`
+	InputImageHashes []string `json:"input_image_hashes,omitempty"`
+	TokenFingerprint string   `json:"token_fingerprint,omitempty"`
+	HistoryID        string   `json:"history_id,omitempty"`
+	Outcome          string   `json:"outcome"`
+	Error            string   `json:"error,omitempty"`
+	ResultURLs       []string `json:"result_urls,omitempty"`
+}
+
+// Options 审计日志配置
+type Options struct {
+	Dir           string
+	Rotation      string
+	MaxSize       int64 // 字节
+	MaxAge        time.Duration
+	MaxBackups    int
+	WriteInterval time.Duration
+}
+
+var (
+	defaultWriter *Writer
+	once          sync.Once
+)
+
+// Init 初始化审计日志，未调用时 Record 为空操作
+func Init(options Options) {
+	once.Do(func() {
+		defaultWriter = NewWriter(options)
+	})
+}
+
+// Enabled 是否已开启审计日志
+func Enabled() bool {
+	return defaultWriter != nil
+}
+
+// Record 异步写入一条审计记录
+func Record(entry *Entry) {
+	if defaultWriter == nil || entry == nil {
+		return
+	}
+	if entry.Timestamp == "" {
+		entry.Timestamp = time.Now().Format(time.RFC3339Nano)
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "序列化审计记录失败: %v\n", err)
+		return
+	}
+	defaultWriter.Push(append(line, '\n'))
+}
+
+// Destroy 停止审计日志并写入剩余记录
+func Destroy() {
+	if defaultWriter != nil {
+		defaultWriter.Stop()
+	}
+}
+
+// Fingerprint 计算 token 指纹，避免在审计日志中保存明文凭证
+func Fingerprint(token string) string {
+	if token == "" {
+		return ""
+	}
+	return HashBytes([]byte(token))[:16]
+}
+
+// HashReader 流式计算内容的 SHA256 十六进制摘要
+func HashReader(r io.Reader) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// HashBytes 计算内容的 SHA256 十六进制摘要
+func HashBytes(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
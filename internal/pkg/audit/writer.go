@@ -0,0 +1,215 @@
+package audit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	filePrefix = "audit-"
+	fileSuffix = ".jsonl"
+)
+
+// Writer 审计日志写入器，缓冲后由后台协程批量追加到文件
+type Writer struct {
+	options  Options
+	buffers  [][]byte
+	mu       sync.Mutex
+	stopChan chan struct{}
+	doneChan chan struct{}
+
+	// 以下字段仅在写入协程中访问
+	period string
+	index  int
+	size   int64
+}
+
+// NewWriter 创建审计日志写入器
+func NewWriter(options Options) *Writer {
+	if options.WriteInterval <= 0 {
+		options.WriteInterval = time.Second
+	}
+	if err := os.MkdirAll(options.Dir, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "创建审计日志目录失败: %v\n", err)
+	}
+
+	writer := &Writer{
+		options:  options,
+		buffers:  make([][]byte, 0),
+		stopChan: make(chan struct{}),
+		doneChan: make(chan struct{}),
+	}
+
+	go writer.work()
+
+	return writer
+}
+
+// Push 添加记录到缓冲区
+func (w *Writer) Push(line []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buffers = append(w.buffers, line)
+}
+
+// Flush 将缓冲区写入文件，必要时轮转
+func (w *Writer) Flush() {
+	w.mu.Lock()
+	if len(w.buffers) == 0 {
+		w.mu.Unlock()
+		return
+	}
+	lines := w.buffers
+	w.buffers = make([][]byte, 0, len(lines))
+	w.mu.Unlock()
+
+	combined := make([]byte, 0)
+	for _, line := range lines {
+		combined = append(combined, line...)
+	}
+
+	path, rotated := w.resolvePath(int64(len(combined)))
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "打开审计日志文件失败: %v\n", err)
+		return
+	}
+	defer f.Close()
+
+	n, err := f.Write(combined)
+	w.size += int64(n)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "写入审计日志文件失败: %v\n", err)
+	}
+	if rotated {
+		w.cleanup(path)
+	}
+}
+
+// Stop 停止写入器，返回前写完剩余记录
+func (w *Writer) Stop() {
+	close(w.stopChan)
+	<-w.doneChan
+}
+
+func (w *Writer) work() {
+	ticker := time.NewTicker(w.options.WriteInterval)
+	defer ticker.Stop()
+	defer close(w.doneChan)
+
+	for {
+		select {
+		case <-ticker.C:
+			w.Flush()
+		case <-w.stopChan:
+			w.Flush()
+			return
+		}
+	}
+}
+
+// resolvePath 计算本次写入的目标文件，周期变化或超过体积上限时切换到新文件
+func (w *Writer) resolvePath(incoming int64) (string, bool) {
+	period := w.currentPeriod()
+	rotated := false
+	if period != w.period {
+		w.period = period
+		w.index = w.latestIndex(period)
+		w.size = fileSize(w.filePath(period, w.index))
+		rotated = true
+	}
+	maxSize := w.options.MaxSize
+	if maxSize > 0 && w.size > 0 && w.size+incoming > maxSize {
+		w.index++
+		w.size = 0
+		rotated = true
+	}
+	return w.filePath(w.period, w.index), rotated
+}
+
+func (w *Writer) currentPeriod() string {
+	now := time.Now()
+	if strings.EqualFold(w.options.Rotation, "hourly") {
+		return now.Format("2006-01-02-15")
+	}
+	return now.Format("2006-01-02")
+}
+
+func (w *Writer) filePath(period string, index int) string {
+	name := filePrefix + period
+	if index > 0 {
+		name = fmt.Sprintf("%s.%d", name, index)
+	}
+	return filepath.Join(w.options.Dir, name+fileSuffix)
+}
+
+// latestIndex 进程重启后继续写入同一周期内编号最大的文件
+func (w *Writer) latestIndex(period string) int {
+	index := 0
+	for {
+		if _, err := os.Stat(w.filePath(period, index+1)); err != nil {
+			return index
+		}
+		index++
+	}
+}
+
+// cleanup 按保留天数和文件数量删除历史审计文件
+func (w *Writer) cleanup(current string) {
+	if w.options.MaxAge <= 0 && w.options.MaxBackups <= 0 {
+		return
+	}
+	entries, err := os.ReadDir(w.options.Dir)
+	if err != nil {
+		return
+	}
+
+	type auditFile struct {
+		path    string
+		modTime time.Time
+	}
+	files := make([]auditFile, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, fileSuffix) {
+			continue
+		}
+		path := filepath.Join(w.options.Dir, name)
+		if path == current {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, auditFile{path: path, modTime: info.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.After(files[j].modTime)
+	})
+
+	cutoff := time.Now().Add(-w.options.MaxAge)
+	for i, file := range files {
+		expired := w.options.MaxAge > 0 && file.modTime.Before(cutoff)
+		// 当前文件也计入保留数量
+		overflow := w.options.MaxBackups > 0 && i+1 >= w.options.MaxBackups
+		if expired || overflow {
+			if err := os.Remove(file.path); err != nil {
+				fmt.Fprintf(os.Stderr, "删除过期审计日志失败: %v\n", err)
+			}
+		}
+	}
+}
+
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
@@ -0,0 +1,115 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestWriter(t *testing.T, options Options) *Writer {
+	t.Helper()
+	options.Dir = t.TempDir()
+	options.WriteInterval = time.Hour
+	writer := NewWriter(options)
+	t.Cleanup(writer.Stop)
+	return writer
+}
+
+func auditFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	matches, err := filepath.Glob(filepath.Join(dir, filePrefix+"*"+fileSuffix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+func TestWriterAppendsJSONLines(t *testing.T) {
+	writer := newTestWriter(t, Options{})
+	writer.Push([]byte(`{"n":1}` + "\n"))
+	writer.Push([]byte(`{"n":2}` + "\n"))
+	writer.Flush()
+	writer.Push([]byte(`{"n":3}` + "\n"))
+	writer.Flush()
+
+	files := auditFiles(t, writer.options.Dir)
+	if len(files) != 1 {
+		t.Fatalf("files = %v, want 1 file", files)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\"n\":1}\n{\"n\":2}\n{\"n\":3}\n"; string(data) != want {
+		t.Errorf("content = %q, want %q", data, want)
+	}
+}
+
+func TestWriterRotatesBySize(t *testing.T) {
+	writer := newTestWriter(t, Options{MaxSize: 20})
+	line := []byte(strings.Repeat("x", 11) + "\n")
+	for i := 0; i < 3; i++ {
+		writer.Push(line)
+		writer.Flush()
+	}
+
+	files := auditFiles(t, writer.options.Dir)
+	if len(files) != 3 {
+		t.Fatalf("files = %v, want 3 files", files)
+	}
+	for _, file := range files {
+		if size := fileSize(file); size != int64(len(line)) {
+			t.Errorf("%s size = %d, want %d", file, size, len(line))
+		}
+	}
+}
+
+func TestWriterKeepsMaxBackups(t *testing.T) {
+	writer := newTestWriter(t, Options{MaxSize: 20, MaxBackups: 2})
+	line := []byte(strings.Repeat("x", 11) + "\n")
+	for i := 0; i < 4; i++ {
+		writer.Push(line)
+		writer.Flush()
+		// 保证文件修改时间可区分新旧
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	files := auditFiles(t, writer.options.Dir)
+	if len(files) != 2 {
+		t.Fatalf("files = %v, want 2 files", files)
+	}
+	period := writer.currentPeriod()
+	for i, want := range []string{writer.filePath(period, 2), writer.filePath(period, 3)} {
+		if files[i] != want {
+			t.Errorf("files[%d] = %s, want %s", i, files[i], want)
+		}
+	}
+}
+
+func TestWriterResumesLatestFile(t *testing.T) {
+	dir := t.TempDir()
+	first := NewWriter(Options{Dir: dir, WriteInterval: time.Hour, MaxSize: 20})
+	line := []byte(strings.Repeat("x", 11) + "\n")
+	for i := 0; i < 2; i++ {
+		first.Push(line)
+		first.Flush()
+	}
+	first.Stop()
+
+	second := NewWriter(Options{Dir: dir, WriteInterval: time.Hour, MaxSize: 100})
+	defer second.Stop()
+	second.Push(line)
+	second.Flush()
+
+	latest := second.filePath(second.currentPeriod(), 1)
+	if size := fileSize(latest); size != int64(2*len(line)) {
+		t.Errorf("%s size = %d, want %d", latest, size, 2*len(line))
+	}
+	if files := auditFiles(t, dir); len(files) != 2 {
+		t.Errorf("files = %v, want 2 files", files)
+	}
+}
@@ -77,15 +77,73 @@ func LoadServiceConfig(env string) (*ServiceConfig, error) {
 
 // SystemConfig 系统配置
 type SystemConfig struct {
-	RequestLog       bool   `mapstructure:"requestLog"`
-	Debug            bool   `mapstructure:"debug"`
-	LogLevel         string `mapstructure:"log_level"`
-	TmpDir           string `mapstructure:"tmpDir"`
-	LogDir           string `mapstructure:"logDir"`
-	LogWriteInterval int    `mapstructure:"logWriteInterval"`
-	LogFileExpires   int64  `mapstructure:"logFileExpires"`
-	PublicDir        string `mapstructure:"publicDir"`
-	TmpFileExpires   int64  `mapstructure:"tmpFileExpires"`
+	RequestLog        bool              `mapstructure:"requestLog"`
+	Debug             bool              `mapstructure:"debug"`
+	LogLevel          string            `mapstructure:"log_level"`
+	TmpDir            string            `mapstructure:"tmpDir"`
+	LogDir            string            `mapstructure:"logDir"`
+	LogWriteInterval  int               `mapstructure:"logWriteInterval"`
+	LogFileExpires    int64             `mapstructure:"logFileExpires"`
+	PublicDir         string            `mapstructure:"publicDir"`
+	TmpFileExpires    int64             `mapstructure:"tmpFileExpires"`
+	VideoB64MaxSize   int64             `mapstructure:"videoB64MaxSize"`   // 视频 b64_json 响应的大小上限（MB），0 表示不限制
+	UploadConcurrency int               `mapstructure:"uploadConcurrency"` // 多图上传并发数
+	UploadChunkSize   int64             `mapstructure:"uploadChunkSize"`   // 分片上传的分片大小（MB），超过该大小的文件分片上传
+	UploadPartRetries int               `mapstructure:"uploadPartRetries"` // 单个分片的最大重试次数
+	UploadStrategies  map[string]string `mapstructure:"uploadStrategies"`  // 各区域首选上传策略（sts/proof），失败时回退到另一种
+	ImageMaxCount     int               `mapstructure:"imageMaxCount"`     // 单次请求 n 的上限
+	ImageConcurrency  int               `mapstructure:"imageConcurrency"`  // 为凑够 n 张图片并发提交的任务数
+	ImageQuality      map[string]string `mapstructure:"imageQuality"`      // OpenAI quality 到分辨率（1k/2k/4k）的映射
+	Audit             AuditConfig       `mapstructure:"audit"`
+	Storage           StorageConfig     `mapstructure:"storage"`
+	Janitor           JanitorConfig     `mapstructure:"janitor"`
+	UploadCache       UploadCacheConfig `mapstructure:"uploadCache"`
+	Variation         VariationConfig   `mapstructure:"variation"`
+	MultiImage        MultiImageConfig  `mapstructure:"multiImage"`
+}
+
+// UploadCacheConfig 图片上传缓存配置
+type UploadCacheConfig struct {
+	Enabled      bool   `mapstructure:"enabled"`
+	Path         string `mapstructure:"path"`         // 持久化索引文件
+	TTL          int    `mapstructure:"ttl"`          // 缓存有效期（秒）
+	MaxEntries   int    `mapstructure:"maxEntries"`   // 最多缓存条数，0 表示不限制
+	SaveInterval int    `mapstructure:"saveInterval"` // 持久化索引的间隔（秒）
+}
+
+// VariationConfig /v1/images/variations 配置
+type VariationConfig struct {
+	Prompt   string  `mapstructure:"prompt"`   // 默认变体提示词
+	Strength float64 `mapstructure:"strength"` // 参考图强度
+}
+
+// MultiImageConfig 多图（组图、连续故事）生成配置
+type MultiImageConfig struct {
+	Models          []string `mapstructure:"models"`          // 支持多图场景的模型
+	DefaultCount    int      `mapstructure:"defaultCount"`    // 未指定 count 时生成的张数
+	MaxCount        int      `mapstructure:"maxCount"`        // count 的上限
+	KeywordFallback bool     `mapstructure:"keywordFallback"` // 未指定 multi_image 时是否按提示词关键词（连续、绘本、故事、N张）自动开启
+}
+
+// JanitorConfig 过期文件清理配置，临时文件和日志的有效期分别取 tmpFileExpires、logFileExpires
+type JanitorConfig struct {
+	Enabled        bool  `mapstructure:"enabled"`
+	Interval       int   `mapstructure:"interval"`       // 清理间隔（秒）
+	TmpMaxSize     int64 `mapstructure:"tmpMaxSize"`     // 临时目录大小上限（MB），0 表示不限制
+	LogMaxSize     int64 `mapstructure:"logMaxSize"`     // 日志目录大小上限（MB），0 表示不限制
+	StorageExpires int64 `mapstructure:"storageExpires"` // 本地转存文件有效期（毫秒），0 表示永久保留
+	StorageMaxSize int64 `mapstructure:"storageMaxSize"` // 本地转存目录大小上限（MB），0 表示不限制
+}
+
+// AuditConfig 审计日志配置
+type AuditConfig struct {
+	Enabled       bool   `mapstructure:"enabled"`
+	Dir           string `mapstructure:"dir"`
+	Rotation      string `mapstructure:"rotation"`      // 轮转周期: daily, hourly
+	MaxSize       int64  `mapstructure:"maxSize"`       // 单个文件最大体积（MB），0 表示不限制
+	MaxAge        int    `mapstructure:"maxAge"`        // 保留天数，0 表示永久保留
+	MaxBackups    int    `mapstructure:"maxBackups"`    // 最多保留文件数，0 表示不限制
+	WriteInterval int    `mapstructure:"writeInterval"` // 写入间隔（毫秒）
 }
 
 // RootDirPath 获取根目录路径
@@ -118,6 +176,64 @@ func (c *SystemConfig) PublicDirPath() string {
 	return filepath.Join(c.RootDirPath(), c.PublicDir)
 }
 
+// StorageConfig 生成结果存储配置
+type StorageConfig struct {
+	Enabled       bool            `mapstructure:"enabled"`
+	Driver        string          `mapstructure:"driver"`        // 存储后端: local, s3
+	LocalDir      string          `mapstructure:"localDir"`      // 本地存储目录
+	PublicBaseURL string          `mapstructure:"publicBaseURL"` // 对外访问地址，为空时使用请求的 Host
+	ResponseMode  string          `mapstructure:"responseMode"`  // both: 同时返回上游与本地链接, local: 仅返回本地链接
+	Prefix        string          `mapstructure:"prefix"`        // 存储路径模板，支持 {kind} {date} {model} {task}
+	S3            S3StorageConfig `mapstructure:"s3"`
+	Signing       SigningConfig   `mapstructure:"signing"`
+}
+
+// SigningConfig 本地文件签名链接配置
+type SigningConfig struct {
+	Secret           string `mapstructure:"secret"`           // HMAC 密钥，为空时不签名
+	TTL              int    `mapstructure:"ttl"`              // 默认有效期（秒）
+	MaxTTL           int    `mapstructure:"maxTTL"`           // 允许申请的最长有效期（秒）
+	RequireSignature bool   `mapstructure:"requireSignature"` // 访问 /files/ 时必须携带有效签名
+}
+
+// S3StorageConfig S3 兼容存储配置
+type S3StorageConfig struct {
+	Endpoint       string `mapstructure:"endpoint"` // 服务地址，如 http://minio:9000
+	Region         string `mapstructure:"region"`
+	Bucket         string `mapstructure:"bucket"`
+	AccessKey      string `mapstructure:"accessKey"`
+	SecretKey      string `mapstructure:"secretKey"`
+	PathStyle      bool   `mapstructure:"pathStyle"`      // 路径寻址（MinIO 需开启）
+	ACL            string `mapstructure:"acl"`            // 对象 ACL，如 public-read，为空时不设置
+	PublicBaseURL  string `mapstructure:"publicBaseURL"`  // 对外访问地址（CDN 等），为空时使用 endpoint
+	Presign        bool   `mapstructure:"presign"`        // 响应中返回预签名 GET 链接
+	PresignExpires int    `mapstructure:"presignExpires"` // 预签名有效期（秒）
+}
+
+// StorageDirPath 获取本地存储目录路径
+func (c *SystemConfig) StorageDirPath() string {
+	if filepath.IsAbs(c.Storage.LocalDir) {
+		return c.Storage.LocalDir
+	}
+	return filepath.Join(c.RootDirPath(), c.Storage.LocalDir)
+}
+
+// UploadCachePath 获取上传缓存索引文件路径
+func (c *SystemConfig) UploadCachePath() string {
+	if filepath.IsAbs(c.UploadCache.Path) {
+		return c.UploadCache.Path
+	}
+	return filepath.Join(c.RootDirPath(), c.UploadCache.Path)
+}
+
+// AuditDirPath 获取审计日志目录路径
+func (c *SystemConfig) AuditDirPath() string {
+	if filepath.IsAbs(c.Audit.Dir) {
+		return c.Audit.Dir
+	}
+	return filepath.Join(c.RootDirPath(), c.Audit.Dir)
+}
+
 // LoadSystemConfig 加载系统配置
 func LoadSystemConfig(env string) (*SystemConfig, error) {
 	configPath := filepath.Join("configs", env, "system.yml")
@@ -136,6 +252,58 @@ func LoadSystemConfig(env string) (*SystemConfig, error) {
 	v.SetDefault("logFileExpires", 2626560000)
 	v.SetDefault("publicDir", "./public")
 	v.SetDefault("tmpFileExpires", 86400000)
+	v.SetDefault("videoB64MaxSize", 200)
+	v.SetDefault("uploadConcurrency", 4)
+	v.SetDefault("uploadChunkSize", 5)
+	v.SetDefault("uploadPartRetries", 3)
+	v.SetDefault("imageMaxCount", 16)
+	v.SetDefault("imageConcurrency", 4)
+	v.SetDefault("imageQuality", map[string]string{
+		"standard": "2k",
+		"hd":       "4k",
+		"low":      "1k",
+		"medium":   "2k",
+		"high":     "4k",
+		"auto":     "2k",
+	})
+	v.SetDefault("audit.enabled", false)
+	v.SetDefault("audit.dir", "./logs/audit")
+	v.SetDefault("audit.rotation", "daily")
+	v.SetDefault("audit.maxSize", 100)
+	v.SetDefault("audit.maxAge", 180)
+	v.SetDefault("audit.maxBackups", 0)
+	v.SetDefault("audit.writeInterval", 1000)
+	v.SetDefault("janitor.enabled", false)
+	v.SetDefault("janitor.interval", 3600)
+	v.SetDefault("janitor.tmpMaxSize", 0)
+	v.SetDefault("janitor.logMaxSize", 0)
+	v.SetDefault("janitor.storageExpires", 0)
+	v.SetDefault("janitor.storageMaxSize", 0)
+	v.SetDefault("uploadCache.enabled", false)
+	v.SetDefault("uploadCache.path", "./data/upload_cache.json")
+	v.SetDefault("uploadCache.ttl", 86400)
+	v.SetDefault("uploadCache.maxEntries", 10000)
+	v.SetDefault("uploadCache.saveInterval", 10)
+	v.SetDefault("variation.prompt", "保持原图的主体、构图和风格，生成一张细节有所变化的相似图片")
+	v.SetDefault("variation.strength", 0.5)
+	v.SetDefault("multiImage.models", []string{"jimeng-4.0", "jimeng-4.1", "jimeng-4.5", "jimeng-4.6", "jimeng-5.0-lite"})
+	v.SetDefault("multiImage.defaultCount", 4)
+	v.SetDefault("multiImage.maxCount", 15)
+	v.SetDefault("multiImage.keywordFallback", false)
+	v.SetDefault("storage.enabled", false)
+	v.SetDefault("storage.driver", "local")
+	v.SetDefault("storage.localDir", "./storage")
+	v.SetDefault("storage.publicBaseURL", "")
+	v.SetDefault("storage.responseMode", "both")
+	v.SetDefault("storage.prefix", "{kind}/{date}")
+	v.SetDefault("storage.s3.region", "us-east-1")
+	v.SetDefault("storage.s3.pathStyle", true)
+	v.SetDefault("storage.s3.presign", false)
+	v.SetDefault("storage.s3.presignExpires", 3600)
+	v.SetDefault("storage.signing.secret", "")
+	v.SetDefault("storage.signing.ttl", 86400)
+	v.SetDefault("storage.signing.maxTTL", 604800)
+	v.SetDefault("storage.signing.requireSignature", false)
 
 	if err := v.ReadInConfig(); err != nil {
 		return nil, err
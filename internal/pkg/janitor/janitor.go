@@ -0,0 +1,242 @@
+package janitor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/logger"
+)
+
+// activeWindow 最近修改的文件可能仍在写入，容量清理时跳过
+const activeWindow = time.Minute
+
+// Rule 单个目录的清理规则
+type Rule struct {
+	Name      string
+	Dir       string
+	MaxAge    time.Duration // 文件有效期，0 表示不按时间清理
+	MaxSize   int64         // 目录总大小上限（字节），0 表示不限制
+	Recursive bool          // 是否清理子目录，否则只处理顶层文件
+}
+
+// Report 单个目录的清理结果
+type Report struct {
+	Name         string
+	Removed      []string
+	RemovedBytes int64
+	Remaining    int64 // 清理后的目录大小
+	Errors       []error
+}
+
+// Options 清理任务配置
+type Options struct {
+	Interval time.Duration
+	Rules    []Rule
+}
+
+// Janitor 定期清理过期文件
+type Janitor struct {
+	options  Options
+	stopChan chan struct{}
+	doneChan chan struct{}
+}
+
+var (
+	defaultJanitor *Janitor
+	once           sync.Once
+)
+
+// Init 启动后台清理任务
+func Init(options Options) {
+	once.Do(func() {
+		defaultJanitor = New(options)
+		defaultJanitor.Start()
+	})
+}
+
+// Destroy 停止后台清理任务
+func Destroy() {
+	if defaultJanitor != nil {
+		defaultJanitor.Stop()
+	}
+}
+
+// New 创建清理任务
+func New(options Options) *Janitor {
+	if options.Interval <= 0 {
+		options.Interval = time.Hour
+	}
+	return &Janitor{
+		options:  options,
+		stopChan: make(chan struct{}),
+		doneChan: make(chan struct{}),
+	}
+}
+
+// Start 立即执行一次清理，之后按间隔执行
+func (j *Janitor) Start() {
+	go func() {
+		defer close(j.doneChan)
+		j.RunOnce()
+		ticker := time.NewTicker(j.options.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				j.RunOnce()
+			case <-j.stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止清理任务并等待当前清理结束
+func (j *Janitor) Stop() {
+	close(j.stopChan)
+	<-j.doneChan
+}
+
+// RunOnce 按规则清理所有目录并记录结果
+func (j *Janitor) RunOnce() []*Report {
+	reports := make([]*Report, 0, len(j.options.Rules))
+	for _, rule := range j.options.Rules {
+		report := Sweep(rule, time.Now())
+		reports = append(reports, report)
+		if len(report.Removed) > 0 {
+			logger.Info(fmt.Sprintf("清理 %s: 删除 %d 个文件，释放 %d bytes，剩余 %d bytes",
+				report.Name, len(report.Removed), report.RemovedBytes, report.Remaining))
+			for _, path := range report.Removed {
+				logger.Debug(fmt.Sprintf("已删除过期文件: %s", path))
+			}
+		}
+		for _, err := range report.Errors {
+			logger.Warn(fmt.Sprintf("清理 %s 失败: %v", report.Name, err))
+		}
+	}
+	return reports
+}
+
+type fileEntry struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// Sweep 按规则清理单个目录：先删除过期文件，再从最旧的文件开始删除直到低于容量上限
+func Sweep(rule Rule, now time.Time) *Report {
+	report := &Report{Name: rule.Name}
+	files, err := listFiles(rule.Dir, rule.Recursive)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			report.Errors = append(report.Errors, err)
+		}
+		return report
+	}
+	sort.Slice(files, func(i, k int) bool {
+		return files[i].modTime.Before(files[k].modTime)
+	})
+
+	var total int64
+	for _, f := range files {
+		total += f.size
+	}
+	kept := files[:0]
+	for _, f := range files {
+		if rule.MaxAge > 0 && now.Sub(f.modTime) > rule.MaxAge {
+			if removeFile(report, f) {
+				total -= f.size
+				continue
+			}
+		}
+		kept = append(kept, f)
+	}
+	if rule.MaxSize > 0 {
+		for _, f := range kept {
+			if total <= rule.MaxSize {
+				break
+			}
+			if now.Sub(f.modTime) < activeWindow {
+				continue
+			}
+			if removeFile(report, f) {
+				total -= f.size
+			}
+		}
+	}
+	if rule.Recursive {
+		removeEmptyDirs(rule.Dir)
+	}
+	report.Remaining = total
+	return report
+}
+
+func removeFile(report *Report, f fileEntry) bool {
+	if err := os.Remove(f.path); err != nil {
+		if !os.IsNotExist(err) {
+			report.Errors = append(report.Errors, err)
+		}
+		return false
+	}
+	report.Removed = append(report.Removed, f.path)
+	report.RemovedBytes += f.size
+	return true
+}
+
+// listFiles 列出目录下的普通文件
+func listFiles(dir string, recursive bool) ([]fileEntry, error) {
+	if !recursive {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		files := make([]fileEntry, 0, len(entries))
+		for _, entry := range entries {
+			if !entry.Type().IsRegular() {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			files = append(files, fileEntry{filepath.Join(dir, entry.Name()), info.Size(), info.ModTime()})
+		}
+		return files, nil
+	}
+
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+	var files []fileEntry
+	err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+		files = append(files, fileEntry{path, info.Size(), info.ModTime()})
+		return nil
+	})
+	return files, err
+}
+
+// removeEmptyDirs 删除清理后留下的空子目录，保留根目录
+func removeEmptyDirs(root string) {
+	var dirs []string
+	filepath.WalkDir(root, func(path string, entry os.DirEntry, err error) error {
+		if err == nil && entry.IsDir() && path != root {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	// 从最深的目录开始删除
+	for i := len(dirs) - 1; i >= 0; i-- {
+		os.Remove(dirs[i])
+	}
+}
@@ -0,0 +1,111 @@
+package janitor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTestFile(t *testing.T, path string, size int, age time.Duration, now time.Time) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatal(err)
+	}
+	modTime := now.Add(-age)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSweep(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name        string
+		rule        Rule
+		files       map[string]time.Duration // 相对路径 -> 文件年龄，每个文件 100 字节
+		wantRemoved []string
+		wantRemain  int64
+	}{
+		{
+			name:        "max age",
+			rule:        Rule{MaxAge: 24 * time.Hour},
+			files:       map[string]time.Duration{"old.log": 48 * time.Hour, "new.log": time.Hour},
+			wantRemoved: []string{"old.log"},
+			wantRemain:  100,
+		},
+		{
+			name:        "max size removes oldest first",
+			rule:        Rule{MaxSize: 250},
+			files:       map[string]time.Duration{"a": 3 * time.Hour, "b": 2 * time.Hour, "c": time.Hour, "d": 2 * time.Minute},
+			wantRemoved: []string{"a", "b"},
+			wantRemain:  200,
+		},
+		{
+			name:        "max size skips active files",
+			rule:        Rule{MaxSize: 50},
+			files:       map[string]time.Duration{"a": time.Hour, "b": time.Second},
+			wantRemoved: []string{"a"},
+			wantRemain:  100,
+		},
+		{
+			name:        "top level only",
+			rule:        Rule{MaxAge: time.Hour},
+			files:       map[string]time.Duration{"old.log": 2 * time.Hour, "audit/old.jsonl": 2 * time.Hour},
+			wantRemoved: []string{"old.log"},
+			wantRemain:  0,
+		},
+		{
+			name:        "recursive",
+			rule:        Rule{MaxAge: time.Hour, Recursive: true},
+			files:       map[string]time.Duration{"images/2024-01-01/a.png": 2 * time.Hour, "images/2024-01-02/b.png": time.Minute},
+			wantRemoved: []string{"images/2024-01-01/a.png"},
+			wantRemain:  100,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, age := range tt.files {
+				writeTestFile(t, filepath.Join(dir, name), 100, age, now)
+			}
+			tt.rule.Dir = dir
+			report := Sweep(tt.rule, now)
+			if len(report.Errors) > 0 {
+				t.Fatalf("Sweep() errors = %v", report.Errors)
+			}
+			removed := map[string]bool{}
+			for _, path := range report.Removed {
+				rel, _ := filepath.Rel(dir, path)
+				removed[filepath.ToSlash(rel)] = true
+			}
+			if len(removed) != len(tt.wantRemoved) {
+				t.Fatalf("removed = %v, want %v", report.Removed, tt.wantRemoved)
+			}
+			for _, name := range tt.wantRemoved {
+				if !removed[name] {
+					t.Errorf("%s not removed, removed = %v", name, report.Removed)
+				}
+			}
+			if report.Remaining != tt.wantRemain {
+				t.Errorf("Remaining = %d, want %d", report.Remaining, tt.wantRemain)
+			}
+		})
+	}
+}
+
+func TestSweepRemovesEmptyDirs(t *testing.T) {
+	now := time.Now()
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "images", "2024-01-01", "a.png"), 10, 48*time.Hour, now)
+	Sweep(Rule{Dir: dir, MaxAge: time.Hour, Recursive: true}, now)
+	if _, err := os.Stat(filepath.Join(dir, "images")); !os.IsNotExist(err) {
+		t.Errorf("empty directories should be removed, stat err = %v", err)
+	}
+	if _, err := os.Stat(dir); err != nil {
+		t.Errorf("root directory should be kept: %v", err)
+	}
+}
@@ -8,8 +8,32 @@ import (
 	"net/url"
 	"sort"
 	"strings"
+	"time"
 )
 
+const (
+	// Algorithm SigV4 签名算法标识
+	Algorithm = "AWS4-HMAC-SHA256"
+	// UnsignedPayload 不对请求体签名时使用的 payload hash
+	UnsignedPayload = "UNSIGNED-PAYLOAD"
+	// TimeFormat x-amz-date 时间格式
+	TimeFormat = "20060102T150405Z"
+)
+
+// SigningRequest 通用 SigV4 签名参数
+type SigningRequest struct {
+	Method          string
+	URL             string
+	Headers         map[string]string // 参与签名的请求头
+	PayloadHash     string            // 请求体 SHA256，为空时按空请求体计算
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Region          string
+	Service         string
+	Timestamp       string // x-amz-date 格式，为空时使用当前时间
+}
+
 // CreateSignature AWS4-HMAC-SHA256 签名生成函数
 // 用于即梦API的请求签名
 func CreateSignature(
@@ -26,44 +50,9 @@ func CreateSignature(
 		region = "cn-north-1"
 	}
 
-	parsedURL, _ := url.Parse(urlStr)
-	pathname := parsedURL.Path
-	if pathname == "" {
-		pathname = "/"
-	}
-	search := parsedURL.RawQuery
-
-	// 创建规范请求
-	timestamp := headers["x-amz-date"]
-	date := timestamp[:8]
-	service := "imagex"
-
-	// 规范化查询参数
-	queryParams := [][]string{}
-	if search != "" {
-		params, _ := url.ParseQuery(search)
-		for key, values := range params {
-			for _, value := range values {
-				queryParams = append(queryParams, []string{key, value})
-			}
-		}
-	}
-
-	// 按键名排序
-	sort.Slice(queryParams, func(i, j int) bool {
-		return queryParams[i][0] < queryParams[j][0]
-	})
-
-	canonicalQueryString := ""
-	parts := []string{}
-	for _, param := range queryParams {
-		parts = append(parts, fmt.Sprintf("%s=%s", param[0], param[1]))
-	}
-	canonicalQueryString = strings.Join(parts, "&")
-
 	// 规范化头部
 	headersToSign := map[string]string{
-		"x-amz-date": timestamp,
+		"x-amz-date": headers["x-amz-date"],
 	}
 
 	if sessionToken != "" {
@@ -71,60 +60,183 @@ func CreateSignature(
 	}
 
 	// 计算 payload hash
-	payloadHash := sha256Hash("")
+	payloadHash := SHA256Hex("")
 	if strings.ToUpper(method) == "POST" && payload != "" {
-		payloadHash = sha256Hash(payload)
+		payloadHash = SHA256Hex(payload)
 		headersToSign["x-amz-content-sha256"] = payloadHash
 	}
 
-	// 构建签名头部列表
-	signedHeadersList := []string{}
-	for key := range headersToSign {
-		signedHeadersList = append(signedHeadersList, strings.ToLower(key))
-	}
-	sort.Strings(signedHeadersList)
-	signedHeaders := strings.Join(signedHeadersList, ";")
+	return SignV4(SigningRequest{
+		Method:          method,
+		URL:             urlStr,
+		Headers:         headersToSign,
+		PayloadHash:     payloadHash,
+		AccessKeyID:     accessKeyID,
+		SecretAccessKey: secretAccessKey,
+		Region:          region,
+		Service:         "imagex",
+		Timestamp:       headers["x-amz-date"],
+	})
+}
 
-	// 构建规范头部字符串
-	canonicalHeadersParts := []string{}
-	for _, key := range signedHeadersList {
-		canonicalHeadersParts = append(canonicalHeadersParts, 
-			fmt.Sprintf("%s:%s\n", key, strings.TrimSpace(headersToSign[key])))
+// SignV4 生成 Authorization 请求头
+func SignV4(req SigningRequest) string {
+	timestamp := req.Timestamp
+	if timestamp == "" {
+		timestamp = time.Now().UTC().Format(TimeFormat)
+	}
+	payloadHash := req.PayloadHash
+	if payloadHash == "" {
+		payloadHash = SHA256Hex("")
 	}
-	canonicalHeaders := strings.Join(canonicalHeadersParts, "")
 
-	// 构建规范请求
+	parsedURL, _ := url.Parse(req.URL)
+	canonicalHeaders, signedHeaders := canonicalizeHeaders(req.Headers)
 	canonicalRequest := strings.Join([]string{
-		strings.ToUpper(method),
-		pathname,
-		canonicalQueryString,
+		strings.ToUpper(req.Method),
+		canonicalURI(parsedURL.Path),
+		canonicalQuery(parsedURL.Query()),
 		canonicalHeaders,
 		signedHeaders,
 		payloadHash,
 	}, "\n")
 
+	credentialScope, signature := sign(req, timestamp, canonicalRequest)
+	return fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		Algorithm, req.AccessKeyID, credentialScope, signedHeaders, signature)
+}
+
+// PresignV4 生成带查询参数签名的 URL，仅对 host 头签名
+func PresignV4(req SigningRequest, expires time.Duration) (string, error) {
+	parsedURL, err := url.Parse(req.URL)
+	if err != nil {
+		return "", err
+	}
+	timestamp := req.Timestamp
+	if timestamp == "" {
+		timestamp = time.Now().UTC().Format(TimeFormat)
+	}
+	date := timestamp[:8]
+
+	query := parsedURL.Query()
+	query.Set("X-Amz-Algorithm", Algorithm)
+	query.Set("X-Amz-Credential", fmt.Sprintf("%s/%s/%s/%s/aws4_request", req.AccessKeyID, date, req.Region, req.Service))
+	query.Set("X-Amz-Date", timestamp)
+	query.Set("X-Amz-Expires", fmt.Sprintf("%d", int64(expires.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+	if req.SessionToken != "" {
+		query.Set("X-Amz-Security-Token", req.SessionToken)
+	}
+
+	canonicalHeaders, signedHeaders := canonicalizeHeaders(map[string]string{"host": parsedURL.Host})
+	canonicalRequest := strings.Join([]string{
+		strings.ToUpper(req.Method),
+		canonicalURI(parsedURL.Path),
+		canonicalQuery(query),
+		canonicalHeaders,
+		signedHeaders,
+		UnsignedPayload,
+	}, "\n")
+
+	_, signature := sign(req, timestamp, canonicalRequest)
+	query.Set("X-Amz-Signature", signature)
+	parsedURL.RawQuery = canonicalQuery(query)
+	return parsedURL.String(), nil
+}
+
+// sign 计算待签名字符串的签名，返回 credential scope 和签名
+func sign(req SigningRequest, timestamp string, canonicalRequest string) (string, string) {
+	region := req.Region
+	if region == "" {
+		region = "cn-north-1"
+	}
+	date := timestamp[:8]
+
 	// 创建待签名字符串
-	credentialScope := fmt.Sprintf("%s/%s/%s/aws4_request", date, region, service)
+	credentialScope := fmt.Sprintf("%s/%s/%s/aws4_request", date, region, req.Service)
 	stringToSign := strings.Join([]string{
-		"AWS4-HMAC-SHA256",
+		Algorithm,
 		timestamp,
 		credentialScope,
-		sha256Hash(canonicalRequest),
+		SHA256Hex(canonicalRequest),
 	}, "\n")
 
 	// 计算签名
-	kDate := hmacSHA256([]byte("AWS4"+secretAccessKey), []byte(date))
+	kDate := hmacSHA256([]byte("AWS4"+req.SecretAccessKey), []byte(date))
 	kRegion := hmacSHA256(kDate, []byte(region))
-	kService := hmacSHA256(kRegion, []byte(service))
+	kService := hmacSHA256(kRegion, []byte(req.Service))
 	kSigning := hmacSHA256(kService, []byte("aws4_request"))
-	signature := hex.EncodeToString(hmacSHA256(kSigning, []byte(stringToSign)))
+	return credentialScope, hex.EncodeToString(hmacSHA256(kSigning, []byte(stringToSign)))
+}
 
-	return fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
-		accessKeyID, credentialScope, signedHeaders, signature)
+// canonicalizeHeaders 构建规范头部字符串和签名头部列表
+func canonicalizeHeaders(headers map[string]string) (string, string) {
+	normalized := make(map[string]string, len(headers))
+	names := make([]string, 0, len(headers))
+	for key, value := range headers {
+		name := strings.ToLower(key)
+		if _, exists := normalized[name]; !exists {
+			names = append(names, name)
+		}
+		normalized[name] = strings.TrimSpace(value)
+	}
+	sort.Strings(names)
+
+	var builder strings.Builder
+	for _, name := range names {
+		builder.WriteString(fmt.Sprintf("%s:%s\n", name, normalized[name]))
+	}
+	return builder.String(), strings.Join(names, ";")
+}
+
+// canonicalURI 按段编码路径，不对 "/" 编码
+func canonicalURI(path string) string {
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery 按键名和值排序并编码查询参数
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := []string{}
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, fmt.Sprintf("%s=%s", uriEncode(key), uriEncode(value)))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode 按 SigV4 规则编码，仅保留 A-Z a-z 0-9 - _ . ~
+func uriEncode(value string) string {
+	var builder strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			builder.WriteByte(c)
+			continue
+		}
+		builder.WriteString(fmt.Sprintf("%%%02X", c))
+	}
+	return builder.String()
 }
 
-// sha256Hash 计算 SHA256 哈希
-func sha256Hash(data string) string {
+// SHA256Hex 计算 SHA256 哈希
+func SHA256Hex(data string) string {
 	hash := sha256.Sum256([]byte(data))
 	return hex.EncodeToString(hash[:])
 }
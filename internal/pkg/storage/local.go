@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LocalStorage 本地磁盘存储
+type LocalStorage struct {
+	dir     string
+	baseURL string
+}
+
+// NewLocalStorage 创建本地存储
+func NewLocalStorage(dir string, baseURL string) (*LocalStorage, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建存储目录失败: %v", err)
+	}
+	return &LocalStorage{
+		dir:     dir,
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}, nil
+}
+
+// Dir 返回存储根目录
+func (s *LocalStorage) Dir() string {
+	return s.dir
+}
+
+// Put 先写入临时文件再重命名，避免读取到写了一半的文件
+func (s *LocalStorage) Put(key string, r io.Reader, contentType string) (*Object, error) {
+	target, err := s.Resolve(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return nil, fmt.Errorf("创建存储目录失败: %v", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时文件失败: %v", err)
+	}
+	size, err := io.Copy(tmp, r)
+	closeErr := tmp.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return nil, fmt.Errorf("写入文件失败: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		os.Remove(tmp.Name())
+		return nil, fmt.Errorf("保存文件失败: %v", err)
+	}
+	return &Object{
+		Key:         key,
+		URL:         s.URL(key),
+		ContentType: contentType,
+		Size:        size,
+	}, nil
+}
+
+// URL 返回文件访问地址，未配置 baseURL 时为相对路径，配置了签名密钥时附带签名
+func (s *LocalStorage) URL(key string) string {
+	if SigningEnabled() {
+		signedPath, _ := SignedPath(key, 0)
+		return s.baseURL + signedPath
+	}
+	return s.baseURL + FileRoutePrefix + key
+}
+
+// SignedURL 生成指定有效期的签名访问地址
+func (s *LocalStorage) SignedURL(key string, ttl time.Duration) (string, time.Time) {
+	signedPath, expiresAt := SignedPath(key, ttl)
+	return s.baseURL + signedPath, expiresAt
+}
+
+// Resolve 将存储 key 转换为磁盘路径，拒绝越出存储目录的路径
+func (s *LocalStorage) Resolve(key string) (string, error) {
+	cleaned := path.Clean("/" + key)
+	if cleaned == "/" || strings.Contains(key, "..") {
+		return "", fmt.Errorf("非法的文件路径 \"%s\"", key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(cleaned)), nil
+}
+
+// OpenLocal 打开本地存储中的文件，未开启本地存储时返回错误
+func OpenLocal(key string) (*os.File, error) {
+	local, ok := defaultStorage.(*LocalStorage)
+	if !ok {
+		return nil, fmt.Errorf("未开启本地存储，无法读取素材 \"%s\"", key)
+	}
+	target, err := local.Resolve(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(target)
+}
@@ -0,0 +1,173 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/signature"
+)
+
+// S3Options S3 兼容存储配置
+type S3Options struct {
+	Endpoint       string
+	Region         string
+	Bucket         string
+	AccessKey      string
+	SecretKey      string
+	PathStyle      bool
+	ACL            string
+	PublicBaseURL  string
+	Presign        bool
+	PresignExpires time.Duration
+	TmpDir         string // 上传前落盘的临时目录，为空时使用系统临时目录
+}
+
+// S3Storage S3 兼容对象存储（AWS S3、MinIO 等）
+type S3Storage struct {
+	options  S3Options
+	endpoint *url.URL
+	client   *http.Client
+}
+
+// NewS3Storage 创建 S3 存储
+func NewS3Storage(options S3Options) (*S3Storage, error) {
+	if options.Endpoint == "" || options.Bucket == "" {
+		return nil, fmt.Errorf("S3 存储需要配置 endpoint 和 bucket")
+	}
+	if options.AccessKey == "" || options.SecretKey == "" {
+		return nil, fmt.Errorf("S3 存储需要配置 accessKey 和 secretKey")
+	}
+	endpoint, err := url.Parse(strings.TrimRight(options.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("S3 endpoint 无效: %s", options.Endpoint)
+	}
+	if options.Region == "" {
+		options.Region = "us-east-1"
+	}
+	if options.PresignExpires <= 0 {
+		options.PresignExpires = time.Hour
+	}
+	return &S3Storage{
+		options:  options,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: 10 * time.Minute},
+	}, nil
+}
+
+// Put 上传对象，先落盘计算 SHA256 后再签名上传
+func (s *S3Storage) Put(key string, r io.Reader, contentType string) (*Object, error) {
+	if s.options.TmpDir != "" {
+		if err := os.MkdirAll(s.options.TmpDir, 0755); err != nil {
+			return nil, fmt.Errorf("创建临时目录失败: %v", err)
+		}
+	}
+	tmp, err := os.CreateTemp(s.options.TmpDir, "jimeng-s3-*")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时文件失败: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), r)
+	if err != nil {
+		return nil, fmt.Errorf("读取文件失败: %v", err)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	payloadHash := hex.EncodeToString(hash.Sum(nil))
+
+	objectURL := s.objectURL(key)
+	parsed, _ := url.Parse(objectURL)
+	timestamp := time.Now().UTC().Format(signature.TimeFormat)
+	headers := map[string]string{
+		"host":                 parsed.Host,
+		"x-amz-date":           timestamp,
+		"x-amz-content-sha256": payloadHash,
+	}
+	if contentType != "" {
+		headers["content-type"] = contentType
+	}
+	if s.options.ACL != "" {
+		headers["x-amz-acl"] = s.options.ACL
+	}
+	authorization := signature.SignV4(signature.SigningRequest{
+		Method:          "PUT",
+		URL:             objectURL,
+		Headers:         headers,
+		PayloadHash:     payloadHash,
+		AccessKeyID:     s.options.AccessKey,
+		SecretAccessKey: s.options.SecretKey,
+		Region:          s.options.Region,
+		Service:         "s3",
+		Timestamp:       timestamp,
+	})
+
+	req, err := http.NewRequest("PUT", objectURL, tmp)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+	for k, v := range headers {
+		if k == "host" {
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Authorization", authorization)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("上传到 S3 失败: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("上传到 S3 失败: HTTP %d %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return &Object{
+		Key:         key,
+		URL:         s.URL(key),
+		ContentType: contentType,
+		Size:        size,
+	}, nil
+}
+
+// URL 返回对象访问地址，开启 presign 时返回带有效期的签名链接
+func (s *S3Storage) URL(key string) string {
+	if s.options.Presign {
+		signed, err := signature.PresignV4(signature.SigningRequest{
+			Method:          "GET",
+			URL:             s.objectURL(key),
+			AccessKeyID:     s.options.AccessKey,
+			SecretAccessKey: s.options.SecretKey,
+			Region:          s.options.Region,
+			Service:         "s3",
+		}, s.options.PresignExpires)
+		if err == nil {
+			return signed
+		}
+	}
+	if s.options.PublicBaseURL != "" {
+		return strings.TrimRight(s.options.PublicBaseURL, "/") + "/" + key
+	}
+	return s.objectURL(key)
+}
+
+// objectURL 根据寻址方式拼接对象地址
+func (s *S3Storage) objectURL(key string) string {
+	escapedKey := (&url.URL{Path: key}).EscapedPath()
+	if s.options.PathStyle {
+		return fmt.Sprintf("%s://%s/%s/%s", s.endpoint.Scheme, s.endpoint.Host, s.options.Bucket, escapedKey)
+	}
+	return fmt.Sprintf("%s://%s.%s/%s", s.endpoint.Scheme, s.options.Bucket, s.endpoint.Host, escapedKey)
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// 签名链接的查询参数
+const (
+	ExpiresParam   = "expires"
+	SignatureParam = "sig"
+)
+
+// SigningOptions 本地文件签名链接配置
+type SigningOptions struct {
+	Secret  string
+	TTL     time.Duration // 默认有效期
+	MaxTTL  time.Duration // 允许申请的最长有效期
+	Require bool          // 访问 /files/ 时必须携带有效签名
+}
+
+var signing SigningOptions
+
+// SetSigningOptions 设置签名链接配置
+func SetSigningOptions(options SigningOptions) {
+	signing = options
+}
+
+// SigningEnabled 是否配置了签名密钥
+func SigningEnabled() bool {
+	return signing.Secret != ""
+}
+
+// SignatureRequired 访问文件是否必须携带签名
+func SignatureRequired() bool {
+	return SigningEnabled() && signing.Require
+}
+
+// ClampTTL 将申请的有效期限制在配置范围内，ttl <= 0 时使用默认值
+func ClampTTL(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		ttl = signing.TTL
+	}
+	if signing.MaxTTL > 0 && ttl > signing.MaxTTL {
+		ttl = signing.MaxTTL
+	}
+	return ttl
+}
+
+// SignedPath 生成带签名的文件访问路径，返回路径和过期时间
+func SignedPath(key string, ttl time.Duration) (string, time.Time) {
+	expiresAt := time.Now().Add(ClampTTL(ttl)).Truncate(time.Second)
+	query := url.Values{}
+	query.Set(ExpiresParam, strconv.FormatInt(expiresAt.Unix(), 10))
+	query.Set(SignatureParam, signKey(key, expiresAt.Unix()))
+	return FileRoutePrefix + key + "?" + query.Encode(), expiresAt
+}
+
+// VerifySignature 校验文件签名和过期时间
+func VerifySignature(key string, expires string, sig string) error {
+	if expires == "" || sig == "" {
+		return fmt.Errorf("缺少签名参数")
+	}
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return fmt.Errorf("签名参数无效")
+	}
+	if time.Now().Unix() > expiresAt {
+		return fmt.Errorf("链接已过期")
+	}
+	if !hmac.Equal([]byte(sig), []byte(signKey(key, expiresAt))) {
+		return fmt.Errorf("签名无效")
+	}
+	return nil
+}
+
+// signKey 计算 HMAC-SHA256(key + "\n" + expires)
+func signKey(key string, expiresAt int64) string {
+	h := hmac.New(sha256.New, []byte(signing.Secret))
+	h.Write([]byte(key + "\n" + strconv.FormatInt(expiresAt, 10)))
+	return hex.EncodeToString(h.Sum(nil))
+}
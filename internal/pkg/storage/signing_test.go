@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignedPath(t *testing.T) {
+	signing = SigningOptions{Secret: "test-secret", TTL: time.Hour, MaxTTL: 2 * time.Hour}
+	defer func() { signing = SigningOptions{} }()
+
+	link, expiresAt := SignedPath("images/2024-01-01/task_0.png", 24*time.Hour)
+	if until := time.Until(expiresAt); until > 2*time.Hour || until < time.Hour {
+		t.Fatalf("有效期未被限制在 maxTTL 内: %v", until)
+	}
+	parsed, err := url.Parse(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := strings.TrimPrefix(parsed.Path, FileRoutePrefix)
+	query := parsed.Query()
+
+	tests := []struct {
+		name    string
+		key     string
+		expires string
+		sig     string
+		wantErr bool
+	}{
+		{"valid", key, query.Get(ExpiresParam), query.Get(SignatureParam), false},
+		{"other key", "images/2024-01-01/task_1.png", query.Get(ExpiresParam), query.Get(SignatureParam), true},
+		{"tampered expiry", key, "9999999999", query.Get(SignatureParam), true},
+		{"expired", key, "1", signKey(key, 1), true},
+		{"missing", key, "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifySignature(tt.key, tt.expires, tt.sig)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifySignature() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/logger"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/utils"
+)
+
+// 响应链接模式
+const (
+	ResponseModeBoth  = "both"
+	ResponseModeLocal = "local"
+)
+
+// FileRoutePrefix 本地文件的访问路径前缀
+const FileRoutePrefix = "/files/"
+
+const downloadUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36"
+
+// Object 已转存的文件
+type Object struct {
+	Key         string
+	URL         string
+	ContentType string
+	Size        int64
+}
+
+// Storage 生成结果存储后端
+type Storage interface {
+	// Put 写入文件内容
+	Put(key string, r io.Reader, contentType string) (*Object, error)
+	// URL 返回文件的访问地址
+	URL(key string) string
+}
+
+// MirrorMeta 转存时用于生成存储路径的任务信息
+type MirrorMeta struct {
+	Kind   string // images 或 videos
+	Model  string
+	TaskID string
+}
+
+// Options 存储配置
+type Options struct {
+	Driver        string
+	LocalDir      string
+	PublicBaseURL string
+	ResponseMode  string
+	Prefix        string // 存储路径模板，为空时使用 DefaultPrefix
+	S3            S3Options
+	Signing       SigningOptions
+}
+
+// DefaultPrefix 默认存储路径模板
+const DefaultPrefix = "{kind}/{date}"
+
+var (
+	defaultStorage Storage
+	responseMode   = ResponseModeBoth
+	keyPrefix      = DefaultPrefix
+	once           sync.Once
+)
+
+// Init 初始化存储后端，未调用时转存功能关闭
+func Init(options Options) error {
+	var err error
+	once.Do(func() {
+		switch strings.ToLower(options.Driver) {
+		case "", "local":
+			defaultStorage, err = NewLocalStorage(options.LocalDir, options.PublicBaseURL)
+		case "s3":
+			defaultStorage, err = NewS3Storage(options.S3)
+		default:
+			err = fmt.Errorf("不支持的存储后端 \"%s\"", options.Driver)
+		}
+		if options.ResponseMode == ResponseModeLocal {
+			responseMode = ResponseModeLocal
+		}
+		if options.Prefix != "" {
+			keyPrefix = options.Prefix
+		}
+		SetSigningOptions(options.Signing)
+	})
+	return err
+}
+
+// Enabled 是否开启了结果转存
+func Enabled() bool {
+	return defaultStorage != nil
+}
+
+// Default 返回当前存储后端
+func Default() Storage {
+	return defaultStorage
+}
+
+// ResponseMode 返回响应中的链接模式
+func ResponseMode() string {
+	return responseMode
+}
+
+// BuildKey 生成存储路径: {prefix}/{task}_{index}.{ext}
+func BuildKey(meta MirrorMeta, index int, ext string) string {
+	taskID := meta.TaskID
+	if taskID == "" {
+		taskID = utils.UUID(false)
+	}
+	taskID = sanitizeSegment(taskID)
+	model := meta.Model
+	if model == "" {
+		model = "unknown"
+	}
+	prefix := strings.NewReplacer(
+		"{kind}", sanitizeSegment(meta.Kind),
+		"{date}", utils.GetDateString(),
+		"{model}", sanitizeSegment(model),
+		"{task}", taskID,
+	).Replace(keyPrefix)
+	key := path.Clean(path.Join("/", prefix, fmt.Sprintf("%s_%d.%s", taskID, index, ext)))
+	return strings.TrimPrefix(key, "/")
+}
+
+// MirrorURLs 下载上游结果并写入存储，返回与输入顺序一致的对象列表
+func MirrorURLs(urls []string, meta MirrorMeta) ([]*Object, error) {
+	if defaultStorage == nil {
+		return nil, fmt.Errorf("未开启结果转存")
+	}
+	objects := make([]*Object, 0, len(urls))
+	for idx, srcURL := range urls {
+		obj, err := MirrorURL(defaultStorage, srcURL, meta, idx)
+		if err != nil {
+			return nil, fmt.Errorf("转存第 %d 个结果失败: %v", idx+1, err)
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+// MirrorURL 下载单个上游文件并写入指定存储
+func MirrorURL(store Storage, srcURL string, meta MirrorMeta, index int) (*Object, error) {
+	req, err := http.NewRequest("GET", srcURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", downloadUserAgent)
+	req.Header.Set("Accept", "*/*")
+
+	client := &http.Client{Timeout: 10 * time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("下载失败: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("下载失败: HTTP %d", resp.StatusCode)
+	}
+
+	body := bufio.NewReaderSize(resp.Body, 512)
+	head, _ := body.Peek(512)
+	contentType := detectContentType(resp.Header.Get("Content-Type"), head)
+	key := BuildKey(meta, index, extensionFor(contentType))
+
+	obj, err := store.Put(key, body, contentType)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info(fmt.Sprintf("结果已转存: %s (%d bytes)", obj.Key, obj.Size))
+	return obj, nil
+}
+
+// SaveBytes 将已下载（或转码后）的内容写入默认存储
+func SaveBytes(data []byte, contentType string, meta MirrorMeta, index int) (*Object, error) {
+	return SaveReader(bytes.NewReader(data), contentType, meta, index)
+}
+
+// SaveReader 将已下载到本地的文件等内容写入默认存储
+func SaveReader(r io.Reader, contentType string, meta MirrorMeta, index int) (*Object, error) {
+	if defaultStorage == nil {
+		return nil, fmt.Errorf("未开启结果转存")
+	}
+	key := BuildKey(meta, index, extensionFor(contentType))
+	obj, err := defaultStorage.Put(key, r, contentType)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info(fmt.Sprintf("结果已转存: %s (%d bytes)", obj.Key, obj.Size))
+	return obj, nil
+}
+
+// detectContentType 优先使用内容探测结果，上游常返回 application/octet-stream
+func detectContentType(header string, head []byte) string {
+	if format := utils.SniffImageFormat(head); format != "" {
+		return utils.ImageMimeType(format)
+	}
+	if mediaType, _, err := mime.ParseMediaType(header); err == nil && mediaType != "application/octet-stream" {
+		return mediaType
+	}
+	if len(head) > 0 {
+		return http.DetectContentType(head)
+	}
+	return "application/octet-stream"
+}
+
+func extensionFor(contentType string) string {
+	switch contentType {
+	case "image/jpeg":
+		return "jpg"
+	case "image/png":
+		return "png"
+	case "image/webp":
+		return "webp"
+	case "image/gif":
+		return "gif"
+	case "image/bmp":
+		return "bmp"
+	case "video/mp4":
+		return "mp4"
+	}
+	return utils.GuessFileExtension(contentType)
+}
+
+func sanitizeSegment(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == '.' {
+			return '_'
+		}
+		return r
+	}, value)
+}
@@ -0,0 +1,214 @@
+package uploader
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/logger"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/utils"
+)
+
+// CacheOptions 上传缓存配置
+type CacheOptions struct {
+	Path         string        // 持久化索引文件路径，为空时仅缓存在内存
+	TTL          time.Duration // 缓存有效期
+	MaxEntries   int           // 最多缓存条数，0 表示不限制
+	SaveInterval time.Duration // 后台持久化索引的间隔
+}
+
+// cacheEntry 缓存的上传结果
+type cacheEntry struct {
+	URI       string `json:"uri"`
+	Width     int    `json:"width,omitempty"`
+	Height    int    `json:"height,omitempty"`
+	Format    string `json:"format,omitempty"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// uploadCache 以内容哈希、token 和区域为键的上传结果缓存
+type uploadCache struct {
+	mu       sync.Mutex
+	options  CacheOptions
+	entries  map[string]*cacheEntry
+	dirty    bool // 索引有未持久化的变更
+	stopChan chan struct{}
+	doneChan chan struct{}
+}
+
+var (
+	defaultCache *uploadCache
+	cacheOnce    sync.Once
+)
+
+// InitCache 初始化上传缓存并加载持久化索引，未调用时不缓存
+func InitCache(options CacheOptions) error {
+	var err error
+	cacheOnce.Do(func() {
+		if options.TTL <= 0 {
+			options.TTL = 24 * time.Hour
+		}
+		if options.SaveInterval <= 0 {
+			options.SaveInterval = 10 * time.Second
+		}
+		cache := &uploadCache{options: options, entries: map[string]*cacheEntry{}}
+		err = cache.load()
+		if options.Path != "" {
+			cache.stopChan = make(chan struct{})
+			cache.doneChan = make(chan struct{})
+			go cache.work()
+		}
+		defaultCache = cache
+	})
+	return err
+}
+
+// DestroyCache 停止后台持久化并写入未保存的索引
+func DestroyCache() {
+	if defaultCache == nil || defaultCache.stopChan == nil {
+		return
+	}
+	close(defaultCache.stopChan)
+	<-defaultCache.doneChan
+}
+
+// work 按间隔持久化索引，上传路径只修改内存，不等待磁盘写入
+func (c *uploadCache) work() {
+	ticker := time.NewTicker(c.options.SaveInterval)
+	defer ticker.Stop()
+	defer close(c.doneChan)
+
+	for {
+		select {
+		case <-ticker.C:
+			c.flush()
+		case <-c.stopChan:
+			c.flush()
+			return
+		}
+	}
+}
+
+// CacheEnabled 是否开启了上传缓存
+func CacheEnabled() bool {
+	return defaultCache != nil
+}
+
+// cacheKey 根据内容摘要生成缓存键，token 只保存指纹
+func cacheKey(contentHash string, refreshToken string, regionInfo *utils.RegionInfo) string {
+	region := "cn"
+	if regionInfo != nil {
+		region = utils.GetRegionCode(regionInfo)
+	}
+	return fmt.Sprintf("%s:%s:%s", contentHash, sha256Hex([]byte(refreshToken))[:16], region)
+}
+
+// get 查找未过期的缓存
+func (c *uploadCache) get(key string) *ImageUploadResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	if time.Since(time.Unix(entry.CreatedAt, 0)) > c.options.TTL {
+		delete(c.entries, key)
+		return nil
+	}
+	return &ImageUploadResult{URI: entry.URI, Width: entry.Width, Height: entry.Height, Format: entry.Format}
+}
+
+// put 写入缓存，索引由后台协程持久化
+func (c *uploadCache) put(key string, result *ImageUploadResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = &cacheEntry{
+		URI:       result.URI,
+		Width:     result.Width,
+		Height:    result.Height,
+		Format:    result.Format,
+		CreatedAt: time.Now().Unix(),
+	}
+	c.prune()
+	c.dirty = true
+}
+
+// flush 索引有变更时写入文件，只在锁内序列化，磁盘写入不阻塞 get/put
+func (c *uploadCache) flush() {
+	c.mu.Lock()
+	if !c.dirty {
+		c.mu.Unlock()
+		return
+	}
+	data, err := json.Marshal(c.entries)
+	c.dirty = false
+	c.mu.Unlock()
+	if err == nil {
+		err = c.save(data)
+	}
+	if err != nil {
+		logger.Warn(fmt.Sprintf("保存上传缓存索引失败: %v", err))
+	}
+}
+
+// prune 删除过期条目，超出数量上限时淘汰最旧的条目
+func (c *uploadCache) prune() {
+	now := time.Now()
+	for key, entry := range c.entries {
+		if now.Sub(time.Unix(entry.CreatedAt, 0)) > c.options.TTL {
+			delete(c.entries, key)
+		}
+	}
+	if c.options.MaxEntries <= 0 || len(c.entries) <= c.options.MaxEntries {
+		return
+	}
+	keys := make([]string, 0, len(c.entries))
+	for key := range c.entries {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return c.entries[keys[i]].CreatedAt < c.entries[keys[j]].CreatedAt
+	})
+	for _, key := range keys[:len(keys)-c.options.MaxEntries] {
+		delete(c.entries, key)
+	}
+}
+
+// load 读取持久化索引，文件不存在时视为空缓存
+func (c *uploadCache) load() error {
+	if c.options.Path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(c.options.Path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取上传缓存索引失败: %v", err)
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		c.entries = map[string]*cacheEntry{}
+		return fmt.Errorf("解析上传缓存索引失败: %v", err)
+	}
+	c.prune()
+	return nil
+}
+
+// save 先写临时文件再重命名，避免进程退出时索引损坏
+func (c *uploadCache) save(data []byte) error {
+	if c.options.Path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(c.options.Path), 0755); err != nil {
+		return err
+	}
+	tmp := c.options.Path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.options.Path)
+}
@@ -0,0 +1,56 @@
+package uploader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/utils"
+)
+
+func TestUploadCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "upload_cache.json")
+	cache := &uploadCache{options: CacheOptions{Path: path, TTL: time.Hour, MaxEntries: 2}, entries: map[string]*cacheEntry{}}
+
+	image := sha256Hex([]byte("image-content"))
+	cn := &utils.RegionInfo{}
+	us := &utils.RegionInfo{IsUS: true, IsInternational: true}
+	key := cacheKey(image, "token-a", cn)
+	if key == cacheKey(image, "token-b", cn) || key == cacheKey(image, "token-a", us) || key == cacheKey(sha256Hex([]byte("other")), "token-a", cn) {
+		t.Fatal("cache key must depend on content, token and region")
+	}
+
+	cache.put(key, &ImageUploadResult{URI: "tos-cn-i/abc", Width: 10, Height: 20, Format: "png"})
+	if got := cache.get(key); got == nil || got.URI != "tos-cn-i/abc" || got.Width != 10 {
+		t.Fatalf("get() = %+v", got)
+	}
+
+	// put 只修改内存，索引由 flush 持久化
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("index written before flush: %v", err)
+	}
+	cache.flush()
+
+	// 重新加载持久化索引
+	reloaded := &uploadCache{options: cache.options, entries: map[string]*cacheEntry{}}
+	if err := reloaded.load(); err != nil {
+		t.Fatal(err)
+	}
+	if got := reloaded.get(key); got == nil || got.URI != "tos-cn-i/abc" {
+		t.Fatalf("reloaded get() = %+v", got)
+	}
+
+	// 过期条目不返回
+	reloaded.entries[key].CreatedAt = time.Now().Add(-2 * time.Hour).Unix()
+	if got := reloaded.get(key); got != nil {
+		t.Fatalf("expired entry returned: %+v", got)
+	}
+
+	// 超出数量上限时淘汰最旧的条目
+	cache.entries["old"] = &cacheEntry{URI: "old", CreatedAt: time.Now().Add(-time.Minute).Unix()}
+	cache.put("new", &ImageUploadResult{URI: "new"})
+	if len(cache.entries) != 2 || cache.entries["old"] != nil {
+		t.Fatalf("entries after prune = %v", cache.entries)
+	}
+}
@@ -0,0 +1,191 @@
+package uploader
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/errors"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/logger"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/utils"
+)
+
+// ChunkOptions 分片上传配置
+type ChunkOptions struct {
+	PartSize   int64  // 分片大小（字节），超过该大小的文件使用分片上传
+	MaxRetries int    // 单个分片的最大重试次数
+	TmpDir     string // 远程图片落盘的临时目录，为空时使用系统临时目录
+}
+
+var chunkOptions = ChunkOptions{
+	PartSize:   5 * 1024 * 1024,
+	MaxRetries: 3,
+}
+
+// SetChunkOptions 设置分片上传参数，非正值保持默认
+func SetChunkOptions(options ChunkOptions) {
+	if options.PartSize > 0 {
+		chunkOptions.PartSize = options.PartSize
+	}
+	if options.MaxRetries >= 0 {
+		chunkOptions.MaxRetries = options.MaxRetries
+	}
+	if options.TmpDir != "" {
+		chunkOptions.TmpDir = options.TmpDir
+	}
+}
+
+// uploadTarget ApplyImageUpload 返回的上传地址
+type uploadTarget struct {
+	URL        string
+	Auth       string
+	RegionInfo *utils.RegionInfo
+}
+
+// chunkResponse 上传节点的响应
+type chunkResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		UploadID string `json:"uploadid"`
+		CRC32    string `json:"crc32"`
+	} `json:"data"`
+}
+
+// transferSingle 单次 POST 上传整个文件，仅用于不超过分片大小的文件
+func transferSingle(ctx context.Context, target uploadTarget, src io.ReaderAt, size int64) error {
+	buffer, err := io.ReadAll(io.NewSectionReader(src, 0, size))
+	if err != nil {
+		return errors.ErrFileUploadFailed(fmt.Sprintf("读取图片失败: %v", err))
+	}
+	crc32Value := utils.CalculateCRC32(buffer)
+	logger.Info(fmt.Sprintf("CRC32: %s", crc32Value))
+
+	_, err = doHTTP(requestConfig{
+		Context: ctx,
+		Method:  "POST",
+		URL:     target.URL,
+		Body:    bytes.NewReader(buffer),
+		Headers: mergeHeaders(buildUploadHeaders(target.RegionInfo, "", nil, true), map[string]string{
+			"Authorization":       target.Auth,
+			"Content-Type":        "application/octet-stream",
+			"Content-CRC32":       crc32Value,
+			"Content-Disposition": "attachment; filename=\"upload.bin\"",
+		}),
+		Timeout: 60 * time.Second,
+	})
+	return err
+}
+
+// transferChunked 分片上传: init 获取 uploadid → transfer 逐片上传（带 CRC32，失败重试）→ finish 合并
+// 每次只在内存中保留一个分片
+func transferChunked(ctx context.Context, target uploadTarget, src io.ReaderAt, size int64) error {
+	partSize := chunkOptions.PartSize
+	partCount := int((size + partSize - 1) / partSize)
+	logger.Info(fmt.Sprintf("使用分片上传: %d 个分片，每片 %d bytes", partCount, partSize))
+
+	initResp, err := chunkRequest(ctx, target, target.URL+"?phase=init&uploadmode=part", nil, nil)
+	if err != nil {
+		return errors.ErrFileUploadFailed(fmt.Sprintf("分片上传初始化失败: %v", err))
+	}
+	uploadID := initResp.Data.UploadID
+	if uploadID == "" {
+		return errors.ErrFileUploadFailed("分片上传初始化未返回 uploadid")
+	}
+
+	partCRCs := make([]string, 0, partCount)
+	buffer := make([]byte, partSize)
+	for partNumber := 1; partNumber <= partCount; partNumber++ {
+		offset := int64(partNumber-1) * partSize
+		n, err := src.ReadAt(buffer, offset)
+		if err != nil && err != io.EOF {
+			return errors.ErrFileUploadFailed(fmt.Sprintf("读取第 %d 个分片失败: %v", partNumber, err))
+		}
+		part := buffer[:n]
+		crc32Value := utils.CalculateCRC32(part)
+		partURL := fmt.Sprintf("%s?phase=transfer&uploadid=%s&part_number=%d", target.URL, uploadID, partNumber)
+		if err := uploadPartWithRetry(ctx, target, partURL, part, crc32Value, partNumber); err != nil {
+			return err
+		}
+		partCRCs = append(partCRCs, fmt.Sprintf("%d:%s", partNumber, crc32Value))
+	}
+
+	finishBody := []byte(strings.Join(partCRCs, ","))
+	if _, err := chunkRequest(ctx, target, fmt.Sprintf("%s?phase=finish&uploadid=%s", target.URL, uploadID), finishBody, map[string]string{
+		"Content-Type": "text/plain;charset=UTF-8",
+	}); err != nil {
+		return errors.ErrFileUploadFailed(fmt.Sprintf("分片上传合并失败: %v", err))
+	}
+	logger.Info(fmt.Sprintf("分片上传完成: %d 个分片", partCount))
+	return nil
+}
+
+// uploadPartWithRetry 上传单个分片，失败时按递增间隔重试
+func uploadPartWithRetry(ctx context.Context, target uploadTarget, partURL string, part []byte, crc32Value string, partNumber int) error {
+	var lastErr error
+	for attempt := 0; attempt <= chunkOptions.MaxRetries; attempt++ {
+		if attempt > 0 {
+			logger.Warn(fmt.Sprintf("第 %d 个分片上传失败，%d 秒后重试 (%d/%d): %v", partNumber, attempt, attempt, chunkOptions.MaxRetries, lastErr))
+			select {
+			case <-time.After(time.Duration(attempt) * time.Second):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		resp, err := chunkRequest(ctx, target, partURL, part, map[string]string{
+			"Content-Type":  "application/octet-stream",
+			"Content-CRC32": crc32Value,
+		})
+		if err == nil && resp.Data.CRC32 != "" && !strings.EqualFold(resp.Data.CRC32, crc32Value) {
+			err = fmt.Errorf("CRC32 校验不一致: 本地 %s, 服务端 %s", crc32Value, resp.Data.CRC32)
+		}
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		lastErr = err
+	}
+	return errors.ErrFileUploadFailed(fmt.Sprintf("第 %d 个分片上传失败: %v", partNumber, lastErr))
+}
+
+// chunkRequest 向上传节点发送分片请求并检查响应码
+func chunkRequest(ctx context.Context, target uploadTarget, url string, body []byte, headers map[string]string) (*chunkResponse, error) {
+	respBody, err := doHTTP(requestConfig{
+		Context: ctx,
+		Method:  "POST",
+		URL:     url,
+		Body:    bytes.NewReader(body),
+		Headers: mergeHeaders(buildUploadHeaders(target.RegionInfo, "", nil, true), mergeHeaders(map[string]string{
+			"Authorization": target.Auth,
+		}, headers)),
+		Timeout: 60 * time.Second,
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp := &chunkResponse{}
+	if err := json.Unmarshal(respBody, resp); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %s", string(respBody))
+	}
+	if resp.Code != 0 && resp.Code != 2000 {
+		return nil, fmt.Errorf("code=%d message=%s", resp.Code, resp.Message)
+	}
+	return resp, nil
+}
+
+// sha256ReaderAt 流式计算数据源的 SHA256
+func sha256ReaderAt(src io.ReaderAt, size int64) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, io.NewSectionReader(src, 0, size)); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
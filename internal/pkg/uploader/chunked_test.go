@@ -0,0 +1,77 @@
+package uploader
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/utils"
+)
+
+func TestTransferChunked(t *testing.T) {
+	saved := chunkOptions
+	defer func() { chunkOptions = saved }()
+	chunkOptions = ChunkOptions{PartSize: 4, MaxRetries: 1}
+
+	data := []byte("0123456789")
+	var (
+		mu         sync.Mutex
+		parts      = map[string][]byte{}
+		failedOnce bool
+		finishBody string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Query().Get("phase") {
+		case "init":
+			fmt.Fprint(w, `{"code":2000,"data":{"uploadid":"u1"}}`)
+		case "transfer":
+			partNumber := r.URL.Query().Get("part_number")
+			if partNumber == "2" && !failedOnce {
+				failedOnce = true
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			if got := r.Header.Get("Content-CRC32"); got != utils.CalculateCRC32(body) {
+				t.Errorf("part %s Content-CRC32 = %s", partNumber, got)
+			}
+			parts[partNumber] = body
+			fmt.Fprintf(w, `{"code":2000,"data":{"crc32":"%s"}}`, utils.CalculateCRC32(body))
+		case "finish":
+			finishBody = string(body)
+			fmt.Fprint(w, `{"code":2000}`)
+		default:
+			t.Errorf("unexpected request %s", r.URL.String())
+		}
+	}))
+	defer server.Close()
+
+	target := uploadTarget{URL: server.URL, Auth: "auth"}
+	if err := transferChunked(context.Background(), target, bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Fatalf("transferChunked() error = %v", err)
+	}
+
+	joined := append(append(append([]byte{}, parts["1"]...), parts["2"]...), parts["3"]...)
+	if !bytes.Equal(joined, data) {
+		t.Errorf("uploaded parts = %q, want %q", joined, data)
+	}
+	if !failedOnce {
+		t.Error("part 2 should be retried")
+	}
+	wantFinish := []string{
+		"1:" + utils.CalculateCRC32([]byte("0123")),
+		"2:" + utils.CalculateCRC32([]byte("4567")),
+		"3:" + utils.CalculateCRC32([]byte("89")),
+	}
+	if finishBody != strings.Join(wantFinish, ",") {
+		t.Errorf("finish body = %q, want %q", finishBody, strings.Join(wantFinish, ","))
+	}
+}
@@ -2,6 +2,7 @@ package uploader
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
 	"crypto/tls"
 	"encoding/hex"
@@ -39,18 +40,17 @@ type ImageUploadResult struct {
 	Format string
 }
 
-// UploadImageBuffer 上传图片缓冲区到 ImageX
-func UploadImageBuffer(
+// uploadImageSTS STS 上传: get_upload_token → ApplyImageUpload → 上传 → CommitImageUpload
+func uploadImageSTS(
+	ctx context.Context,
 	requestFn RequestFunc,
-	imageBuffer []byte,
+	src io.ReaderAt,
+	size int64,
+	_ string,
 	refreshToken string,
 	regionInfo *utils.RegionInfo,
 ) (*ImageUploadResult, error) {
-	if requestFn == nil {
-		return nil, errors.ErrFileUploadFailed("request 函数未提供")
-	}
-
-	logger.Info("开始上传图片 Buffer 到 ImageX")
+	logger.Info("开始上传图片到 ImageX")
 	// 1. 获取上传令牌
 	uploadToken, err := requestFn("POST", "/mweb/v1/get_upload_token", refreshToken, &RequestOptions{
 		Body: map[string]interface{}{
@@ -60,6 +60,9 @@ func UploadImageBuffer(
 	if err != nil {
 		return nil, errors.ErrFileUploadFailed(fmt.Sprintf("获取上传令牌失败: %v", err))
 	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	accessKey := toString(uploadToken["access_key_id"])
 	secretKey := toString(uploadToken["secret_access_key"])
@@ -78,9 +81,8 @@ func UploadImageBuffer(
 		return nil, errors.ErrFileUploadFailed("上传凭证不完整")
 	}
 
-	fileSize := len(imageBuffer)
-	crc32Value := utils.CalculateCRC32(imageBuffer)
-	logger.Info(fmt.Sprintf("图片大小: %d, CRC32: %s", fileSize, crc32Value))
+	fileSize := size
+	logger.Info(fmt.Sprintf("图片大小: %d", fileSize))
 
 	imageXBase := utils.GetImageXURL(regionInfo)
 	randomStr := randomString(10)
@@ -110,6 +112,7 @@ func UploadImageBuffer(
 	logger.Info(fmt.Sprintf("申请上传 URL: %s", applyURL))
 
 	applyRespBody, err := doHTTP(requestConfig{
+		Context:     ctx,
 		Method:      "GET",
 		URL:         applyURL,
 		Headers:     buildUploadHeaders(regionInfo, authorization, authHeaders, true),
@@ -162,20 +165,15 @@ func UploadImageBuffer(
 	uploadURL := fmt.Sprintf("https://%s/upload/v1/%s", uploadHost, storeURI)
 	logger.Info(fmt.Sprintf("上传文件到 %s", uploadURL))
 
-	uploadHeaders := map[string]string{
-		"Authorization":       fileAuth,
-		"Content-Type":        "application/octet-stream",
-		"Content-CRC32":       crc32Value,
-		"Content-Disposition": "attachment; filename=\"upload.bin\"",
+	transfer := transferSingle
+	if size > chunkOptions.PartSize {
+		transfer = transferChunked
 	}
-
-	if _, err := doHTTP(requestConfig{
-		Method:  "POST",
-		URL:     uploadURL,
-		Body:    bytes.NewReader(imageBuffer),
-		Headers: mergeHeaders(buildUploadHeaders(regionInfo, "", nil, true), uploadHeaders),
-		Timeout: 60 * time.Second,
-	}); err != nil {
+	if err := transfer(ctx, uploadTarget{
+		URL:        uploadURL,
+		Auth:       fileAuth,
+		RegionInfo: regionInfo,
+	}, src, size); err != nil {
 		logger.Error(fmt.Sprintf("文件上传请求失败: %v", err))
 		return nil, err
 	}
@@ -204,6 +202,7 @@ func UploadImageBuffer(
 	)
 
 	commitResp, err := doHTTP(requestConfig{
+		Context: ctx,
 		Method:  "POST",
 		URL:     commitURL,
 		Body:    bytes.NewReader(commitBytes),
@@ -255,39 +254,9 @@ func UploadImageBuffer(
 	}, nil
 }
 
-// UploadImageFromURL 下载图片并上传
-func UploadImageFromURL(
-	requestFn RequestFunc,
-	imageURL string,
-	refreshToken string,
-	regionInfo *utils.RegionInfo,
-) (*ImageUploadResult, error) {
-	client := &http.Client{Timeout: 45 * time.Second}
-	req, err := http.NewRequest("GET", imageURL, nil)
-	if err != nil {
-		return nil, errors.ErrFileUploadFailed(fmt.Sprintf("构建下载请求失败: %v", err))
-	}
-	req.Header.Set("User-Agent", uploaderUserAgent)
-	req.Header.Set("Accept", "*/*")
-	req.Header.Set("Accept-Language", "zh-CN,zh;q=0.9")
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, errors.ErrFileUploadFailed(fmt.Sprintf("下载图片失败: %v", err))
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode >= 400 {
-		return nil, errors.ErrFileUploadFailed(fmt.Sprintf("下载图片失败: HTTP %d", resp.StatusCode))
-	}
-	buffer, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	return UploadImageBuffer(requestFn, buffer, refreshToken, regionInfo)
-}
-
 // 工具函数
 type requestConfig struct {
+	Context     context.Context
 	Method      string
 	URL         string
 	Body        io.Reader
@@ -309,7 +278,11 @@ func doHTTP(cfg requestConfig) ([]byte, error) {
 		Timeout:   cfg.Timeout,
 		Transport: transport,
 	}
-	req, err := http.NewRequest(cfg.Method, cfg.URL, cfg.Body)
+	ctx := cfg.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	req, err := http.NewRequestWithContext(ctx, cfg.Method, cfg.URL, cfg.Body)
 	if err != nil {
 		return nil, err
 	}
@@ -318,6 +291,9 @@ func doHTTP(cfg requestConfig) ([]byte, error) {
 	}
 	resp, err := client.Do(req)
 	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		return nil, errors.ErrFileUploadFailed(fmt.Sprintf("请求 %s 失败: %v", cfg.URL, err))
 	}
 	defer resp.Body.Close()
@@ -0,0 +1,113 @@
+package uploader
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/errors"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/logger"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/utils"
+)
+
+// uploadImageProof 凭证上传: get_upload_image_proof 获取凭证后以 multipart 表单上传到 ImageX
+// 表单内容边读数据源边写出，不在内存中缓存整个文件；上传地址按 token 所属区域选择
+func uploadImageProof(
+	ctx context.Context,
+	requestFn RequestFunc,
+	src io.ReaderAt,
+	size int64,
+	scene string,
+	refreshToken string,
+	regionInfo *utils.RegionInfo,
+) (*ImageUploadResult, error) {
+	logger.Info("开始凭证上传图片")
+	if scene == "" {
+		scene = SceneImage
+	}
+	head := make([]byte, 512)
+	n, _ := src.ReadAt(head, 0)
+	mimeType := http.DetectContentType(head[:n])
+	filename := fmt.Sprintf("%s.%s", utils.UUID(false), utils.GuessFileExtension(mimeType))
+
+	proofResult, err := requestFn("POST", "/mweb/v1/get_upload_image_proof", refreshToken, &RequestOptions{
+		Body: map[string]interface{}{
+			"scene":     scene,
+			"file_name": filename,
+			"file_size": size,
+		},
+	})
+	if err != nil {
+		return nil, errors.ErrFileUploadFailed(fmt.Sprintf("获取上传凭证失败: %v", err))
+	}
+	proofInfo := mapValue(proofResult, "proof_info")
+	if len(proofInfo) == 0 {
+		return nil, errors.ErrFileUploadFailed("获取上传凭证失败")
+	}
+	imageURI := toString(proofInfo["image_uri"])
+	if imageURI == "" {
+		return nil, errors.ErrFileUploadFailed("上传凭证缺少 image_uri")
+	}
+
+	reader, writer := io.Pipe()
+	defer reader.Close()
+	form := multipart.NewWriter(writer)
+	go func() {
+		part, err := form.CreateFormFile("file", filename)
+		if err == nil {
+			_, err = io.Copy(part, io.NewSectionReader(src, 0, size))
+		}
+		if err == nil {
+			err = form.Close()
+		}
+		writer.CloseWithError(err)
+	}()
+
+	headers := map[string]string{}
+	for k, v := range stringMap(proofInfo["headers"]) {
+		headers[k] = v
+	}
+	headers["Content-Type"] = form.FormDataContentType()
+	query := url.Values{}
+	for k, v := range stringMap(proofInfo["query_params"]) {
+		query.Set(k, v)
+	}
+	uploadURL := utils.GetImageXURL(regionInfo) + "/"
+	if len(query) > 0 {
+		uploadURL += "?" + query.Encode()
+	}
+
+	if _, err := doHTTP(requestConfig{
+		Context: ctx,
+		Method:  "POST",
+		URL:     uploadURL,
+		Body:    reader,
+		Headers: mergeHeaders(buildUploadHeaders(regionInfo, "", nil, true), headers),
+		Timeout: 60 * time.Second,
+	}); err != nil {
+		logger.Error(fmt.Sprintf("凭证上传请求失败: %v", err))
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("凭证上传完成: %s", imageURI))
+	return &ImageUploadResult{URI: imageURI}, nil
+}
+
+// stringMap 将凭证中的 headers/query_params 转为字符串映射，Content-Type 由表单决定
+func stringMap(value interface{}) map[string]string {
+	result := map[string]string{}
+	if m, ok := value.(map[string]interface{}); ok {
+		for k, v := range m {
+			if strings.EqualFold(k, "Content-Type") {
+				continue
+			}
+			result[k] = fmt.Sprintf("%v", v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,384 @@
+package uploader
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/errors"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/logger"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/storage"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/utils"
+)
+
+// 上传策略
+const (
+	StrategySTS   = "sts"   // get_upload_token + ApplyImageUpload/CommitImageUpload，大文件分片上传
+	StrategyProof = "proof" // get_upload_image_proof + multipart 表单上传
+)
+
+// 凭证上传的场景
+const (
+	SceneImage      = "aigc_image"  // 图片生成的参考图
+	SceneVideoCover = "video_cover" // 视频生成的首尾帧
+)
+
+// AssetScheme 本地素材 ID 前缀，指向本地存储中的文件，也可直接使用 /files/{key}
+const AssetScheme = "asset://"
+
+// maxInputSize URL、data URI 和 BASE64 输入的大小上限
+const maxInputSize = 100 * 1024 * 1024
+
+// Uploader 统一的图片上传接口
+type Uploader interface {
+	// Upload 上传图片，返回 ImageX URI 及图片元数据
+	Upload(ctx context.Context, input Input, refreshToken string, regionInfo *utils.RegionInfo) (*ImageUploadResult, error)
+}
+
+// Input 上传输入，Data、Reader、Value 三选一
+type Input struct {
+	Data   []byte      // 内存中的图片数据
+	Reader io.ReaderAt // 可随机读取的数据源（磁盘文件、multipart 文件）
+	Size   int64       // Reader 的数据长度
+	Value  string      // http(s) URL、data URI、BASE64 或本地素材 ID
+}
+
+// BytesInput 内存数据输入
+func BytesInput(data []byte) Input {
+	return Input{Data: data}
+}
+
+// ReaderInput 可随机读取的数据源输入
+func ReaderInput(r io.ReaderAt, size int64) Input {
+	return Input{Reader: r, Size: size}
+}
+
+// StringInput URL、data URI、BASE64 或本地素材 ID 输入
+func StringInput(value string) Input {
+	return Input{Value: value}
+}
+
+type strategyFunc func(ctx context.Context, requestFn RequestFunc, src io.ReaderAt, size int64, scene string, refreshToken string, regionInfo *utils.RegionInfo) (*ImageUploadResult, error)
+
+var strategies = map[string]strategyFunc{
+	StrategySTS:   uploadImageSTS,
+	StrategyProof: uploadImageProof,
+}
+
+// regionStrategies 各区域的首选上传策略，未配置的区域使用 STS
+var regionStrategies = map[string]string{}
+
+// SetRegionStrategies 设置各区域（cn/us/hk/jp/sg）的首选上传策略
+func SetRegionStrategies(values map[string]string) error {
+	for region, name := range values {
+		name = strings.ToLower(name)
+		if _, ok := strategies[name]; !ok {
+			return fmt.Errorf("区域 %s 的上传策略 \"%s\" 不支持，可选 sts/proof", region, name)
+		}
+		regionStrategies[strings.ToLower(region)] = name
+	}
+	return nil
+}
+
+// strategyOrder 返回区域的策略尝试顺序: 首选策略在前，另一种作为回退
+func strategyOrder(regionInfo *utils.RegionInfo) []string {
+	region := "cn"
+	if regionInfo != nil {
+		region = strings.ToLower(utils.GetRegionCode(regionInfo))
+	}
+	if regionStrategies[region] == StrategyProof {
+		return []string{StrategyProof, StrategySTS}
+	}
+	return []string{StrategySTS, StrategyProof}
+}
+
+// ImageUploader 按区域选择上传策略，失败时回退到另一种策略
+type ImageUploader struct {
+	requestFn RequestFunc
+	scene     string
+}
+
+// New 创建图片生成场景的上传器
+func New(requestFn RequestFunc) Uploader {
+	return NewForScene(requestFn, SceneImage)
+}
+
+// NewForScene 创建指定场景的上传器，视频首尾帧使用 SceneVideoCover
+func NewForScene(requestFn RequestFunc, scene string) Uploader {
+	return &ImageUploader{requestFn: requestFn, scene: scene}
+}
+
+// Upload 解析输入后上传，开启上传缓存时相同内容直接复用已上传的 URI
+// 无论使用哪种策略，结果中缺失的宽高和格式都由本地探测补齐
+func (u *ImageUploader) Upload(ctx context.Context, input Input, refreshToken string, regionInfo *utils.RegionInfo) (*ImageUploadResult, error) {
+	if u.requestFn == nil {
+		return nil, errors.ErrFileUploadFailed("request 函数未提供")
+	}
+	src, size, cleanup, err := openInput(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	if size == 0 {
+		return nil, errors.ErrFileUploadFailed("图片内容为空")
+	}
+
+	var key string
+	if defaultCache != nil {
+		contentHash, err := sha256ReaderAt(src, size)
+		if err != nil {
+			return nil, errors.ErrFileUploadFailed(fmt.Sprintf("读取图片失败: %v", err))
+		}
+		key = cacheKey(contentHash, refreshToken, regionInfo)
+		if u.scene != SceneImage {
+			// 不同场景的上传结果分开缓存，避免视频首尾帧复用图片场景的 URI
+			key += ":" + u.scene
+		}
+		if cached := defaultCache.get(key); cached != nil {
+			logger.Info(fmt.Sprintf("命中上传缓存，跳过上传: %s", cached.URI))
+			return cached, nil
+		}
+	}
+
+	result, err := u.upload(ctx, src, size, refreshToken, regionInfo)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := utils.DetectImageInfoReader(io.NewSectionReader(src, 0, size)); err == nil {
+		if result.Width == 0 || result.Height == 0 {
+			result.Width, result.Height = info.Width, info.Height
+		}
+		if result.Format == "" {
+			result.Format = info.Format
+		}
+	}
+	if key != "" {
+		defaultCache.put(key, result)
+	}
+	return result, nil
+}
+
+// upload 使用区域首选策略上传，失败时回退到另一种策略
+func (u *ImageUploader) upload(ctx context.Context, src io.ReaderAt, size int64, refreshToken string, regionInfo *utils.RegionInfo) (*ImageUploadResult, error) {
+	var failures []string
+	for _, name := range strategyOrder(regionInfo) {
+		result, err := strategies[name](ctx, u.requestFn, src, size, u.scene, refreshToken, regionInfo)
+		if err == nil {
+			return result, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		logger.Warn(fmt.Sprintf("%s 策略上传失败: %v", name, err))
+		failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+	}
+	return nil, errors.ErrFileUploadFailed(fmt.Sprintf("所有上传策略均失败: %s", strings.Join(failures, "; ")))
+}
+
+// ReadInput 按与上传相同的规则解析输入并读出完整内容，供需要在上传前处理图片的场景使用
+func ReadInput(ctx context.Context, input Input) ([]byte, error) {
+	src, size, cleanup, err := openInput(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	data, err := io.ReadAll(io.NewSectionReader(src, 0, size))
+	if err != nil {
+		return nil, errors.ErrFileUploadFailed(fmt.Sprintf("读取图片失败: %v", err))
+	}
+	return data, nil
+}
+
+// PeekInput 读取输入开头最多 n 个字节，用于上传前探测图片格式；远程 URL 不下载，直接返回 nil
+func PeekInput(ctx context.Context, input Input, n int) ([]byte, error) {
+	if input.Reader == nil && input.Data == nil && isRemoteURL(strings.TrimSpace(input.Value)) {
+		return nil, nil
+	}
+	src, size, cleanup, err := openInput(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	if size < int64(n) {
+		n = int(size)
+	}
+	header := make([]byte, n)
+	if _, err := src.ReadAt(header, 0); err != nil && err != io.EOF {
+		return nil, errors.ErrFileUploadFailed(fmt.Sprintf("读取图片失败: %v", err))
+	}
+	return header, nil
+}
+
+// openInput 将输入转换为可随机读取的数据源，返回的 cleanup 用于释放临时文件
+func openInput(ctx context.Context, input Input) (io.ReaderAt, int64, func(), error) {
+	noop := func() {}
+	switch {
+	case input.Reader != nil:
+		return input.Reader, input.Size, noop, nil
+	case input.Data != nil:
+		return bytes.NewReader(input.Data), int64(len(input.Data)), noop, nil
+	}
+
+	value := strings.TrimSpace(input.Value)
+	switch {
+	case value == "":
+		return nil, 0, noop, errors.ErrFileUploadFailed("图片输入为空")
+	case isRemoteURL(value):
+		file, size, err := downloadToTemp(ctx, value)
+		if err != nil {
+			return nil, 0, noop, err
+		}
+		return file, size, func() {
+			file.Close()
+			os.Remove(file.Name())
+		}, nil
+	case isAssetID(value):
+		key, err := verifyAsset(value)
+		if err != nil {
+			return nil, 0, noop, errors.ErrFileUploadFailed(err.Error()).SetHTTPStatusCode(403)
+		}
+		file, err := storage.OpenLocal(key)
+		if err != nil {
+			return nil, 0, noop, errors.ErrFileUploadFailed(fmt.Sprintf("读取本地素材失败: %v", err))
+		}
+		stat, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return nil, 0, noop, errors.ErrFileUploadFailed(fmt.Sprintf("读取本地素材失败: %v", err))
+		}
+		return file, stat.Size(), func() { file.Close() }, nil
+	}
+
+	if err := ValidateStringInput(value); err != nil {
+		return nil, 0, noop, errors.ErrFileUploadFailed(err.Error())
+	}
+	data, err := base64.StdEncoding.DecodeString(base64Payload(value))
+	if err != nil {
+		return nil, 0, noop, errors.ErrFileUploadFailed(fmt.Sprintf("解析BASE64失败: %v", err))
+	}
+	return bytes.NewReader(data), int64(len(data)), noop, nil
+}
+
+// ValidateStringInput 检查字符串输入是否为 URL、data URI、BASE64 或本地素材 ID，不下载也不解码到内存
+func ValidateStringInput(value string) error {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return fmt.Errorf("图片输入为空")
+	}
+	if isRemoteURL(value) {
+		return nil
+	}
+	if isAssetID(value) {
+		_, err := verifyAsset(value)
+		return err
+	}
+	payload := base64Payload(value)
+	if int64(len(payload))/4*3 > maxInputSize {
+		return fmt.Errorf("图片大小超过限制(>100MB)")
+	}
+	if len(payload) == 0 || len(payload)%4 != 0 {
+		return fmt.Errorf("不支持的图片输入，仅支持 URL、data URI、BASE64 和本地素材 ID")
+	}
+	if _, err := io.Copy(io.Discard, base64.NewDecoder(base64.StdEncoding, strings.NewReader(payload))); err != nil {
+		return fmt.Errorf("不支持的图片输入，仅支持 URL、data URI、BASE64 和本地素材 ID")
+	}
+	return nil
+}
+
+func isRemoteURL(value string) bool {
+	return strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://")
+}
+
+func isAssetID(value string) bool {
+	return strings.HasPrefix(value, AssetScheme) || strings.HasPrefix(value, storage.FileRoutePrefix)
+}
+
+// parseAsset 拆分素材 ID，返回本地存储 key 和签名参数
+func parseAsset(value string) (string, url.Values) {
+	key := strings.TrimPrefix(strings.TrimPrefix(value, AssetScheme), storage.FileRoutePrefix)
+	if idx := strings.Index(key, "#"); idx >= 0 {
+		key = key[:idx]
+	}
+	var query url.Values
+	if idx := strings.Index(key, "?"); idx >= 0 {
+		query, _ = url.ParseQuery(key[idx+1:])
+		key = key[:idx]
+	}
+	return key, query
+}
+
+// verifyAsset 按访问 /files/ 的规则校验素材签名：要求签名时必须携带有效签名，携带了签名时也必须有效
+func verifyAsset(value string) (string, error) {
+	key, query := parseAsset(value)
+	sig := query.Get(storage.SignatureParam)
+	if storage.SignatureRequired() || (storage.SigningEnabled() && sig != "") {
+		if err := storage.VerifySignature(key, query.Get(storage.ExpiresParam), sig); err != nil {
+			return "", fmt.Errorf("本地素材 \"%s\" 签名校验失败: %v", key, err)
+		}
+	}
+	return key, nil
+}
+
+// base64Payload 去掉 data URI 头部，返回 BASE64 内容
+func base64Payload(value string) string {
+	if utils.IsDataURI(value) {
+		return utils.RemoveDataURIHeader(value)
+	}
+	return value
+}
+
+// downloadToTemp 将远程图片流式下载到临时文件
+func downloadToTemp(ctx context.Context, imageURL string) (*os.File, int64, error) {
+	client := &http.Client{Timeout: 60 * time.Second}
+	req, err := http.NewRequestWithContext(ctx, "GET", imageURL, nil)
+	if err != nil {
+		return nil, 0, errors.ErrFileUploadFailed(fmt.Sprintf("构建下载请求失败: %v", err))
+	}
+	req.Header.Set("User-Agent", uploaderUserAgent)
+	req.Header.Set("Accept", "*/*")
+	req.Header.Set("Accept-Language", "zh-CN,zh;q=0.9")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, 0, ctx.Err()
+		}
+		return nil, 0, errors.ErrFileUploadFailed(fmt.Sprintf("下载图片失败: %v", err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return nil, 0, errors.ErrFileUploadFailed(fmt.Sprintf("下载图片失败: HTTP %d", resp.StatusCode))
+	}
+	if resp.ContentLength > maxInputSize {
+		return nil, 0, errors.ErrFileUploadFailed("图片大小超过限制(>100MB)")
+	}
+	if chunkOptions.TmpDir != "" {
+		if err := os.MkdirAll(chunkOptions.TmpDir, 0755); err != nil {
+			return nil, 0, errors.ErrFileUploadFailed(fmt.Sprintf("创建临时目录失败: %v", err))
+		}
+	}
+	tmp, err := os.CreateTemp(chunkOptions.TmpDir, "jimeng-upload-*")
+	if err != nil {
+		return nil, 0, errors.ErrFileUploadFailed(fmt.Sprintf("创建临时文件失败: %v", err))
+	}
+	size, err := io.Copy(tmp, io.LimitReader(resp.Body, maxInputSize+1))
+	if err == nil && size > maxInputSize {
+		err = fmt.Errorf("图片大小超过限制(>100MB)")
+	}
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		if ctx.Err() != nil {
+			return nil, 0, ctx.Err()
+		}
+		return nil, 0, errors.ErrFileUploadFailed(fmt.Sprintf("下载图片失败: %v", err))
+	}
+	return tmp, size, nil
+}
@@ -0,0 +1,196 @@
+package uploader
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/storage"
+	"github.com/gloryhry/jimeng-api-go/internal/pkg/utils"
+)
+
+func TestOpenInput(t *testing.T) {
+	content := []byte("image-bytes")
+	encoded := base64.StdEncoding.EncodeToString(content)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(content)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		input   Input
+		wantErr bool
+	}{
+		{name: "bytes", input: BytesInput(content)},
+		{name: "url", input: StringInput(server.URL + "/a.png")},
+		{name: "data uri", input: StringInput("data:image/png;base64," + encoded)},
+		{name: "base64", input: StringInput(encoded)},
+		{name: "invalid", input: StringInput("not an image!"), wantErr: true},
+		{name: "asset without local storage", input: StringInput(AssetScheme + "images/a.png"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, size, cleanup, err := openInput(context.Background(), tt.input)
+			defer cleanup()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("openInput() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got, _ := io.ReadAll(io.NewSectionReader(src, 0, size))
+			if string(got) != string(content) {
+				t.Errorf("content = %q, want %q", got, content)
+			}
+		})
+	}
+}
+
+func TestPeekInput(t *testing.T) {
+	requested := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+	}))
+	defer server.Close()
+
+	encoded := base64.StdEncoding.EncodeToString([]byte("image-bytes"))
+	tests := []struct {
+		name  string
+		input Input
+		want  string
+	}{
+		{name: "bytes", input: BytesInput([]byte("image-bytes")), want: "image"},
+		{name: "shorter than n", input: BytesInput([]byte("img")), want: "img"},
+		{name: "base64", input: StringInput(encoded), want: "image"},
+		{name: "url is not downloaded", input: StringInput(server.URL + "/a.png")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PeekInput(context.Background(), tt.input, 5)
+			if err != nil || string(got) != tt.want {
+				t.Errorf("PeekInput() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+	if requested {
+		t.Error("PeekInput() should not download remote URLs")
+	}
+}
+
+func TestUploadFallback(t *testing.T) {
+	saved, savedRegions := strategies, regionStrategies
+	defer func() { strategies, regionStrategies = saved, savedRegions }()
+
+	var calls, scenes []string
+	fake := func(name string, fail bool) strategyFunc {
+		return func(ctx context.Context, requestFn RequestFunc, src io.ReaderAt, size int64, scene string, refreshToken string, regionInfo *utils.RegionInfo) (*ImageUploadResult, error) {
+			calls = append(calls, name)
+			scenes = append(scenes, scene)
+			if fail {
+				return nil, errors.New("failed")
+			}
+			return &ImageUploadResult{URI: name + "-uri"}, nil
+		}
+	}
+	strategies = map[string]strategyFunc{
+		StrategySTS:   fake(StrategySTS, true),
+		StrategyProof: fake(StrategyProof, false),
+	}
+	regionStrategies = map[string]string{}
+
+	noopRequest := func(method, uri, refreshToken string, options *RequestOptions) (map[string]interface{}, error) {
+		return nil, nil
+	}
+	result, err := New(noopRequest).Upload(context.Background(), BytesInput([]byte("x")), "token", nil)
+	if err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	if result.URI != "proof-uri" {
+		t.Errorf("URI = %s, want proof-uri", result.URI)
+	}
+	if len(calls) != 2 || calls[0] != StrategySTS || calls[1] != StrategyProof {
+		t.Errorf("calls = %v, want [sts proof]", calls)
+	}
+	if _, err := NewForScene(noopRequest, SceneVideoCover).Upload(context.Background(), BytesInput([]byte("x")), "token", nil); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	if len(scenes) != 4 || scenes[1] != SceneImage || scenes[3] != SceneVideoCover {
+		t.Errorf("scenes = %v, want image scene then video cover scene", scenes)
+	}
+
+	if err := SetRegionStrategies(map[string]string{"US": "proof"}); err != nil {
+		t.Fatal(err)
+	}
+	if order := strategyOrder(utils.ParseRegionFromToken("us-token")); order[0] != StrategyProof {
+		t.Errorf("us strategy order = %v, want proof first", order)
+	}
+	if err := SetRegionStrategies(map[string]string{"cn": "ftp"}); err == nil {
+		t.Error("SetRegionStrategies() should reject unknown strategy")
+	}
+}
+
+func TestValidateStringInput(t *testing.T) {
+	tests := []struct {
+		value   string
+		wantErr bool
+	}{
+		{value: "https://example.com/a.png"},
+		{value: "data:image/png;base64,aGVsbG8="},
+		{value: "aGVsbG8="},
+		{value: AssetScheme + "images/a.png"},
+		{value: "/files/images/a.png?expires=1&sig=x"},
+		{value: "", wantErr: true},
+		{value: "ftp://example.com/a.png", wantErr: true},
+		{value: "data:image/png;base64,@@@@", wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := ValidateStringInput(tt.value); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateStringInput(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+		}
+	}
+}
+
+func TestAssetSignatureRequired(t *testing.T) {
+	storage.SetSigningOptions(storage.SigningOptions{Secret: "secret", TTL: time.Minute, Require: true})
+	defer storage.SetSigningOptions(storage.SigningOptions{})
+
+	signed, _ := storage.SignedPath("images/a.png", time.Minute)
+	query := signed[strings.Index(signed, "?"):]
+	expired := fmt.Sprintf("?%s=%d&%s=x", storage.ExpiresParam, time.Now().Add(-time.Minute).Unix(), storage.SignatureParam)
+	tests := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{name: "signed file path", value: signed},
+		{name: "signed asset id", value: AssetScheme + "images/a.png" + query},
+		{name: "missing signature", value: "/files/images/a.png", wantErr: true},
+		{name: "missing signature asset id", value: AssetScheme + "images/a.png", wantErr: true},
+		{name: "forged signature", value: "/files/images/a.png" + strings.Replace(query, "sig=", "sig=0", 1), wantErr: true},
+		{name: "signature for another key", value: "/files/images/b.png" + query, wantErr: true},
+		{name: "expired", value: "/files/images/a.png" + expired, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateStringInput(tt.value); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateStringInput() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr {
+				return
+			}
+			// 上传路径同样拒绝，不会打开本地文件
+			_, _, _, err := openInput(context.Background(), StringInput(tt.value))
+			if err == nil || !strings.Contains(err.Error(), "签名校验失败") {
+				t.Errorf("openInput() error = %v, want signature error", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"strings"
+
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/webp"
+)
+
+// OutputImageFormats 支持的输出格式，暂无 webp 编码器，不提供 webp（不指定 output_format 时保持上游格式）
+var OutputImageFormats = []string{"png", "jpeg"}
+
+// NormalizeOutputFormat 规范化输出格式名称，不支持时返回空字符串
+func NormalizeOutputFormat(format string) string {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "png":
+		return "png"
+	case "jpeg", "jpg":
+		return "jpeg"
+	}
+	return ""
+}
+
+// ConvertImage 将图片转码为指定格式，返回转码后的内容和 MIME 类型
+// compression 为 0-100 的压缩程度，仅对 jpeg 生效（作为质量参数），png 为无损格式忽略该参数
+// 目标为 png 且源格式相同时原样返回
+func ConvertImage(data []byte, format string, compression int) ([]byte, string, error) {
+	source := SniffImageFormat(data)
+	if source == "" {
+		return nil, "", fmt.Errorf("无法识别的图片格式")
+	}
+	target := NormalizeOutputFormat(format)
+	if target == "" {
+		return nil, "", fmt.Errorf("不支持的输出格式 \"%s\"", format)
+	}
+	if target == source && target != "jpeg" {
+		return data, ImageMimeType(source), nil
+	}
+	if target == source && compression >= 100 {
+		return data, ImageMimeType(source), nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("解码图片失败: %v", err)
+	}
+	var buf bytes.Buffer
+	switch target {
+	case "png":
+		err = png.Encode(&buf, img)
+	case "jpeg":
+		quality := compression
+		if quality < 1 {
+			quality = 1
+		} else if quality > 100 {
+			quality = 100
+		}
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("编码图片失败: %v", err)
+	}
+	return buf.Bytes(), ImageMimeType(target), nil
+}
@@ -0,0 +1,45 @@
+package utils
+
+import "testing"
+
+func TestConvertImage(t *testing.T) {
+	pngData := encodeTestImage(t, "png", 8, 6)
+	jpegData := encodeTestImage(t, "jpeg", 8, 6)
+
+	tests := []struct {
+		name     string
+		data     []byte
+		format   string
+		wantMime string
+		wantErr  bool
+	}{
+		{"png to jpeg", pngData, "jpeg", "image/jpeg", false},
+		{"jpg alias", pngData, "jpg", "image/jpeg", false},
+		{"jpeg to png", jpegData, "png", "image/png", false},
+		{"png passthrough", pngData, "png", "image/png", false},
+		{"webp not supported", pngData, "webp", "", true},
+		{"unknown format", pngData, "tiff", "", true},
+		{"invalid image", []byte("not an image"), "png", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, mimeType, err := ConvertImage(tt.data, tt.format, 80)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ConvertImage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if mimeType != tt.wantMime {
+				t.Errorf("mime = %s, want %s", mimeType, tt.wantMime)
+			}
+			info, err := DetectImageInfo(out)
+			if err != nil {
+				t.Fatalf("DetectImageInfo() error = %v", err)
+			}
+			if info.MimeType != tt.wantMime || info.Width != 8 || info.Height != 6 {
+				t.Errorf("output = %+v, want %s 8x6", info, tt.wantMime)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// BuildInpaintMask 将 OpenAI 风格的遮罩转为上游使用的黑白遮罩 PNG，白色为重绘区域
+// 遮罩含透明像素时以透明区域为重绘区域，否则按亮度取白色区域；尺寸必须与原图一致
+func BuildInpaintMask(maskData []byte, width, height int) ([]byte, error) {
+	mask, _, err := image.Decode(bytes.NewReader(maskData))
+	if err != nil {
+		return nil, fmt.Errorf("解码遮罩失败: %v", err)
+	}
+	bounds := mask.Bounds()
+	if bounds.Dx() != width || bounds.Dy() != height {
+		return nil, fmt.Errorf("遮罩尺寸 %dx%d 与原图 %dx%d 不一致", bounds.Dx(), bounds.Dy(), width, height)
+	}
+	if hasTransparency(mask) {
+		return encodeMask(mask, isTransparent)
+	}
+	return encodeMask(mask, func(c color.Color) bool {
+		return color.GrayModel.Convert(c).(color.Gray).Y >= 128
+	})
+}
+
+// AlphaSniffLength HasAlphaChannel 需要的文件头字节数，覆盖 PNG 签名和 IHDR 中的颜色类型
+const AlphaSniffLength = 26
+
+// HasAlphaChannel 根据 PNG 文件头 IHDR 的颜色类型判断图片是否可能含透明像素（灰度+透明、RGBA、调色板）
+// 只检查文件头，不解码图片；非 PNG 或文件头不完整时返回 false
+func HasAlphaChannel(header []byte) bool {
+	if len(header) < AlphaSniffLength || SniffImageFormat(header) != "png" || string(header[12:16]) != "IHDR" {
+		return false
+	}
+	switch header[25] {
+	case 3, 4, 6:
+		return true
+	}
+	return false
+}
+
+// MaskFromAlpha 从原图的透明通道生成遮罩，透明区域为重绘区域；原图不含透明像素时返回 nil
+func MaskFromAlpha(imageData []byte) ([]byte, error) {
+	img, _, err := image.Decode(bytes.NewReader(imageData))
+	if err != nil {
+		return nil, fmt.Errorf("解码图片失败: %v", err)
+	}
+	if !hasTransparency(img) {
+		return nil, nil
+	}
+	return encodeMask(img, isTransparent)
+}
+
+func isTransparent(c color.Color) bool {
+	_, _, _, a := c.RGBA()
+	return a == 0
+}
+
+func hasTransparency(img image.Image) bool {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		return false
+	}
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if isTransparent(img.At(x, y)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func encodeMask(img image.Image, inpaint func(color.Color) bool) ([]byte, error) {
+	bounds := img.Bounds()
+	mask := image.NewGray(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	painted := false
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if inpaint(img.At(x, y)) {
+				mask.SetGray(x-bounds.Min.X, y-bounds.Min.Y, color.Gray{Y: 255})
+				painted = true
+			}
+		}
+	}
+	if !painted {
+		return nil, fmt.Errorf("遮罩中没有需要重绘的区域")
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, mask); err != nil {
+		return nil, fmt.Errorf("编码遮罩失败: %v", err)
+	}
+	return buf.Bytes(), nil
+}
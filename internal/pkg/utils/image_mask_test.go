@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+// encodeMaskSource 生成左半部分为 left、右半部分为 right 的 PNG
+func encodeMaskSource(t *testing.T, left, right color.Color) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			if x < 2 {
+				img.Set(x, y, left)
+			} else {
+				img.Set(x, y, right)
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func decodeMask(t *testing.T, data []byte) []uint8 {
+	t.Helper()
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decode mask: %v", err)
+	}
+	gray, ok := img.(*image.Gray)
+	if !ok {
+		t.Fatalf("mask should be grayscale, got %T", img)
+	}
+	return []uint8{gray.GrayAt(0, 0).Y, gray.GrayAt(3, 1).Y}
+}
+
+func TestBuildInpaintMask(t *testing.T) {
+	transparent := color.NRGBA{}
+	opaque := color.NRGBA{R: 10, G: 20, B: 30, A: 255}
+	white := color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	black := color.NRGBA{A: 255}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		width   int
+		height  int
+		want    []uint8
+		wantErr bool
+	}{
+		{name: "transparent area is inpainted", data: encodeMaskSource(t, transparent, opaque), width: 4, height: 2, want: []uint8{255, 0}},
+		{name: "white area without alpha", data: encodeMaskSource(t, black, white), width: 4, height: 2, want: []uint8{0, 255}},
+		{name: "size mismatch", data: encodeMaskSource(t, transparent, opaque), width: 8, height: 2, wantErr: true},
+		{name: "nothing to inpaint", data: encodeMaskSource(t, black, black), width: 4, height: 2, wantErr: true},
+		{name: "invalid data", data: []byte("not an image"), width: 4, height: 2, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := BuildInpaintMask(tt.data, tt.width, tt.height)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BuildInpaintMask() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got := decodeMask(t, out)
+			if got[0] != tt.want[0] || got[1] != tt.want[1] {
+				t.Errorf("mask pixels = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaskFromAlpha(t *testing.T) {
+	opaque := color.NRGBA{R: 10, G: 20, B: 30, A: 255}
+
+	out, err := MaskFromAlpha(encodeMaskSource(t, opaque, opaque))
+	if err != nil || out != nil {
+		t.Fatalf("opaque image should not produce a mask, got %v, %v", out, err)
+	}
+
+	out, err = MaskFromAlpha(encodeMaskSource(t, opaque, color.NRGBA{}))
+	if err != nil {
+		t.Fatalf("MaskFromAlpha() error = %v", err)
+	}
+	if got := decodeMask(t, out); got[0] != 0 || got[1] != 255 {
+		t.Errorf("mask pixels = %v, want [0 255]", got)
+	}
+}
+
+func TestHasAlphaChannel(t *testing.T) {
+	opaque := color.NRGBA{R: 10, G: 20, B: 30, A: 255}
+	tests := []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{name: "png with transparency", data: encodeMaskSource(t, opaque, color.NRGBA{}), want: true},
+		{name: "opaque png", data: encodeMaskSource(t, opaque, opaque)},
+		{name: "jpeg", data: encodeTestImage(t, "jpeg", 4, 2)},
+		{name: "truncated header", data: []byte("\x89PNG\r\n\x1a\n")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasAlphaChannel(tt.data); got != tt.want {
+				t.Errorf("HasAlphaChannel() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
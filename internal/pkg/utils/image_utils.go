@@ -0,0 +1,163 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+)
+
+// ImageInfo 图片内容探测结果
+type ImageInfo struct {
+	Format   string `json:"format"`
+	MimeType string `json:"mime_type"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+}
+
+// 探测图片格式所需读取的头部字节数，足以覆盖 webp/bmp 的尺寸字段
+const imageSniffLength = 64
+
+var imageMimeTypes = map[string]string{
+	"png":  "image/png",
+	"jpeg": "image/jpeg",
+	"webp": "image/webp",
+	"gif":  "image/gif",
+	"bmp":  "image/bmp",
+}
+
+// SupportedImageFormats 支持上传的图片格式
+var SupportedImageFormats = []string{"png", "jpeg", "webp", "gif", "bmp"}
+
+// SniffImageFormat 根据魔数判断图片格式，无法识别时返回空字符串
+func SniffImageFormat(header []byte) string {
+	switch {
+	case bytes.HasPrefix(header, []byte("\x89PNG\r\n\x1a\n")):
+		return "png"
+	case bytes.HasPrefix(header, []byte{0xFF, 0xD8, 0xFF}):
+		return "jpeg"
+	case len(header) >= 12 && bytes.Equal(header[0:4], []byte("RIFF")) && bytes.Equal(header[8:12], []byte("WEBP")):
+		return "webp"
+	case bytes.HasPrefix(header, []byte("GIF87a")), bytes.HasPrefix(header, []byte("GIF89a")):
+		return "gif"
+	case bytes.HasPrefix(header, []byte("BM")) && len(header) >= 26:
+		return "bmp"
+	default:
+		return ""
+	}
+}
+
+// ImageMimeType 返回图片格式对应的 MIME 类型
+func ImageMimeType(format string) string {
+	if mimeType, ok := imageMimeTypes[format]; ok {
+		return mimeType
+	}
+	return "application/octet-stream"
+}
+
+// ImageExtension 返回图片 MIME 类型对应的常用扩展名
+func ImageExtension(mimeType string) string {
+	if mimeType == "image/jpeg" {
+		return "jpg"
+	}
+	for format, value := range imageMimeTypes {
+		if value == mimeType {
+			return format
+		}
+	}
+	return GuessFileExtension(mimeType)
+}
+
+// DetectImageInfo 探测图片格式并解析宽高
+func DetectImageInfo(data []byte) (*ImageInfo, error) {
+	return DetectImageInfoReader(bytes.NewReader(data))
+}
+
+// DetectImageInfoReader 从数据流中探测图片格式并解析宽高
+func DetectImageInfoReader(r io.Reader) (*ImageInfo, error) {
+	header := make([]byte, imageSniffLength)
+	n, err := io.ReadFull(r, header)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		if err == io.EOF {
+			return nil, fmt.Errorf("图片内容为空")
+		}
+		return nil, err
+	}
+	header = header[:n]
+
+	format := SniffImageFormat(header)
+	if format == "" {
+		return nil, fmt.Errorf("不支持的图片格式，仅支持 png/jpeg/webp/gif/bmp")
+	}
+
+	info := &ImageInfo{Format: format, MimeType: ImageMimeType(format)}
+	switch format {
+	case "webp":
+		info.Width, info.Height, err = parseWebPSize(header)
+	case "bmp":
+		info.Width, info.Height, err = parseBMPSize(header)
+	default:
+		var cfg image.Config
+		cfg, _, err = image.DecodeConfig(io.MultiReader(bytes.NewReader(header), r))
+		info.Width, info.Height = cfg.Width, cfg.Height
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析 %s 图片尺寸失败: %v", format, err)
+	}
+	if info.Width <= 0 || info.Height <= 0 {
+		return nil, fmt.Errorf("图片尺寸无效: %dx%d", info.Width, info.Height)
+	}
+	return info, nil
+}
+
+// parseWebPSize 解析 VP8/VP8L/VP8X 块中的宽高
+func parseWebPSize(header []byte) (int, int, error) {
+	if len(header) < 30 {
+		return 0, 0, fmt.Errorf("数据过短")
+	}
+	chunk := string(header[12:16])
+	payload := header[20:]
+	switch chunk {
+	case "VP8 ":
+		// 帧头: 3 字节 frame tag + 3 字节起始码 9d 01 2a
+		if !bytes.Equal(payload[3:6], []byte{0x9d, 0x01, 0x2a}) {
+			return 0, 0, fmt.Errorf("VP8 起始码无效")
+		}
+		width := int(binary.LittleEndian.Uint16(payload[6:8]) & 0x3fff)
+		height := int(binary.LittleEndian.Uint16(payload[8:10]) & 0x3fff)
+		return width, height, nil
+	case "VP8L":
+		if payload[0] != 0x2f {
+			return 0, 0, fmt.Errorf("VP8L 签名无效")
+		}
+		bits := binary.LittleEndian.Uint32(payload[1:5])
+		width := int(bits&0x3fff) + 1
+		height := int((bits>>14)&0x3fff) + 1
+		return width, height, nil
+	case "VP8X":
+		width := int(uint32(payload[4])|uint32(payload[5])<<8|uint32(payload[6])<<16) + 1
+		height := int(uint32(payload[7])|uint32(payload[8])<<8|uint32(payload[9])<<16) + 1
+		return width, height, nil
+	default:
+		return 0, 0, fmt.Errorf("未知的 WebP 块类型 %q", chunk)
+	}
+}
+
+// parseBMPSize 解析 BITMAPINFOHEADER 中的宽高，高度为负表示自上而下存储
+func parseBMPSize(header []byte) (int, int, error) {
+	dibSize := binary.LittleEndian.Uint32(header[14:18])
+	if dibSize == 12 {
+		// BITMAPCOREHEADER 使用 16 位宽高
+		return int(binary.LittleEndian.Uint16(header[18:20])), int(binary.LittleEndian.Uint16(header[20:22])), nil
+	}
+	width := int(int32(binary.LittleEndian.Uint32(header[18:22])))
+	height := int(int32(binary.LittleEndian.Uint32(header[22:26])))
+	if height < 0 {
+		height = -height
+	}
+	return width, height, nil
+}
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func encodeTestImage(t *testing.T, format string, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "png":
+		err = png.Encode(&buf, img)
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, nil)
+	case "gif":
+		err = gif.Encode(&buf, img, nil)
+	}
+	if err != nil {
+		t.Fatalf("encode %s: %v", format, err)
+	}
+	return buf.Bytes()
+}
+
+func testBMPHeader(width, height int32) []byte {
+	header := make([]byte, 54)
+	copy(header, "BM")
+	binary.LittleEndian.PutUint32(header[14:18], 40)
+	binary.LittleEndian.PutUint32(header[18:22], uint32(width))
+	binary.LittleEndian.PutUint32(header[22:26], uint32(height))
+	return header
+}
+
+func testWebPVP8XHeader(width, height int) []byte {
+	header := make([]byte, 30)
+	copy(header[0:4], "RIFF")
+	copy(header[8:12], "WEBP")
+	copy(header[12:16], "VP8X")
+	w, h := width-1, height-1
+	header[24], header[25], header[26] = byte(w), byte(w>>8), byte(w>>16)
+	header[27], header[28], header[29] = byte(h), byte(h>>8), byte(h>>16)
+	return header
+}
+
+func TestDetectImageInfo(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       []byte
+		wantFormat string
+		wantWidth  int
+		wantHeight int
+		wantErr    bool
+	}{
+		{"png", encodeTestImage(t, "png", 32, 16), "png", 32, 16, false},
+		{"jpeg", encodeTestImage(t, "jpeg", 24, 48), "jpeg", 24, 48, false},
+		{"gif", encodeTestImage(t, "gif", 10, 20), "gif", 10, 20, false},
+		{"bmp top-down", testBMPHeader(640, -480), "bmp", 640, 480, false},
+		{"webp VP8X", testWebPVP8XHeader(1920, 1080), "webp", 1920, 1080, false},
+		{"text", []byte("hello, this is not an image at all"), "", 0, 0, true},
+		{"empty", nil, "", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := DetectImageInfo(tt.data)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("DetectImageInfo() expected error, got %+v", info)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DetectImageInfo() error = %v", err)
+			}
+			if info.Format != tt.wantFormat || info.Width != tt.wantWidth || info.Height != tt.wantHeight {
+				t.Errorf("DetectImageInfo() = %s %dx%d, want %s %dx%d",
+					info.Format, info.Width, info.Height, tt.wantFormat, tt.wantWidth, tt.wantHeight)
+			}
+		})
+	}
+}